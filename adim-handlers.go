@@ -1,8 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
 )
 
 func handleReadCheck(w http.ResponseWriter, r *http.Request) {
@@ -36,7 +41,8 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := cfg.db.DeleteAllUsers(r.Context())
+	// Admins survive so the caller isn't locked out of the admin routes.
+	err := cfg.db.DeleteNonAdminUsers(r.Context())
 	if err != nil {
 		responseError(w, http.StatusInternalServerError, "Error deleting users", err)
 		return
@@ -46,5 +52,74 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	cfg.fileServerHits.Load()
 
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("hits set to 0 and user table reset"))
+	w.Write([]byte("hits set to 0 and non-admin users deleted"))
+}
+
+func (cfg *apiConfig) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Role string `json:"role"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	adminID := contextUser(r.Context()).ID
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	if !auth.ValidRole(params.Role) {
+		responseError(w, http.StatusBadRequest, "role must be one of user, moderator or admin", nil)
+		return
+	}
+
+	// Admins can't demote themselves, so there is always someone left who
+	// can manage roles.
+	if userID == adminID {
+		responseError(w, http.StatusBadRequest, "Can't change your own role", nil)
+		return
+	}
+
+	user, err := cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: params.Role,
+	})
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not find user", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, databaseUserToUser(user))
+}
+
+// handleUnlockUser clears an account's failed login attempts, ending any
+// lockout early. Lockouts of the IPs involved are left to expire.
+func (cfg *apiConfig) handleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not find user", err)
+		return
+	}
+
+	err = cfg.clearLoginFailures(r.Context(), user.Email)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't unlock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	maxAPITokenNameLen = 100
+	maxAPITokenTTLDays = 365
+)
+
+// APIToken is a personal access token as listed to its owner. The secret
+// itself is only ever returned once, when the token is created.
+type APIToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func databaseAPITokenToAPIToken(token database.ApiToken) APIToken {
+	result := APIToken{
+		ID:        token.ID,
+		CreatedAt: token.CreatedAt,
+		Name:      token.Name,
+		Prefix:    token.Prefix,
+		Scopes:    token.Scopes,
+	}
+	if token.ExpiresAt.Valid {
+		result.ExpiresAt = &token.ExpiresAt.Time
+	}
+	if token.LastUsedAt.Valid {
+		result.LastUsedAt = &token.LastUsedAt.Time
+	}
+
+	return result
+}
+
+func (cfg *apiConfig) handleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	type jsonResParams struct {
+		APIToken
+		Token string `json:"token"`
+	}
+
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Error decoding parameters", err)
+		return
+	}
+
+	if params.Name == "" || len(params.Name) > maxAPITokenNameLen {
+		responseError(w, http.StatusBadRequest, fmt.Sprintf("Name must be 1 to %d characters", maxAPITokenNameLen), nil)
+		return
+	}
+
+	scopes := []string{}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			responseError(w, http.StatusBadRequest, fmt.Sprintf("Unknown scope %q", scope), nil)
+			return
+		}
+		if !auth.HasScope(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		responseError(w, http.StatusBadRequest, "At least one scope is required", nil)
+		return
+	}
+
+	if params.ExpiresInDays < 0 || params.ExpiresInDays > maxAPITokenTTLDays {
+		responseError(w, http.StatusBadRequest, fmt.Sprintf("expires_in_days must be between 0 and %d", maxAPITokenTTLDays), nil)
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if params.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, params.ExpiresInDays), Valid: true}
+	}
+
+	token, prefix, err := auth.MakeAPIToken()
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating token", err)
+		return
+	}
+
+	apiToken, err := cfg.db.CreateAPIToken(r.Context(), database.CreateAPITokenParams{
+		UserID:    userID,
+		Name:      params.Name,
+		TokenHash: auth.HashToken(token),
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't save token", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, jsonResParams{
+		APIToken: databaseAPITokenToAPIToken(apiToken),
+		Token:    token,
+	})
+}
+
+func (cfg *apiConfig) handleGetAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	dbTokens, err := cfg.db.ListAPITokens(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting tokens", err)
+		return
+	}
+
+	tokens := []APIToken{}
+	for _, token := range dbTokens {
+		tokens = append(tokens, databaseAPITokenToAPIToken(token))
+	}
+
+	jsonResponse(w, http.StatusOK, tokens)
+}
+
+func (cfg *apiConfig) handleDeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid token ID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeAPIToken(r.Context(), database.RevokeAPITokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't revoke token", err)
+		return
+	}
+	if revoked == 0 {
+		responseError(w, http.StatusNotFound, "Could not find token", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
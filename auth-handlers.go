@@ -1,7 +1,10 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -15,12 +18,6 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 
-	type jsonResParams struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
-	}
-
 	decoder := json.NewDecoder(r.Body)
 	params := jsonReqParams{}
 	err := decoder.Decode(&params)
@@ -33,6 +30,10 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !cfg.reserveLoginAttempt(w, r, params.Email) {
+		return
+	}
+
 	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
 		responseError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
@@ -41,48 +42,49 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, r *http.Request) {
 
 	err = auth.CheckPasswordHash(params.Password, user.HashedPassword)
 	if err != nil {
+		if !errors.Is(err, auth.ErrPasswordMismatch) {
+			log.Printf("Can't check password of user %s: %s", user.ID, err)
+		}
 		responseError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
 		return
 	}
+	cfg.releaseLoginAttempt(r, params.Email)
 
-	token, err := auth.MakeJWT(user.ID, cfg.seceret, time.Hour)
-	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Error creating access JWT", err)
-		return
+	if auth.NeedsRehash(user.HashedPassword, cfg.passwordParams) {
+		cfg.rehashPassword(r.Context(), user.ID, params.Password)
 	}
 
-	refreshToken, err := auth.MakeRefreshToken()
-	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Error creating refresh token", err)
+	if user.SuspendedAt.Valid {
+		responseError(w, http.StatusForbidden, "Account suspended", nil)
 		return
 	}
 
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 60),
-		UserID:    user.ID,
-	})
-	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Couldn't save refresh token", err)
+	if user.TotpEnabledAt.Valid {
+		type jsonMFAResParams struct {
+			MFARequired bool   `json:"mfa_required"`
+			MFAToken    string `json:"mfa_token"`
+		}
+
+		mfaToken, err := auth.MakeMFAToken(user.ID, cfg.jwtKeys, mfaTokenTTL)
+		if err != nil {
+			responseError(w, http.StatusInternalServerError, "Error creating MFA token", err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, jsonMFAResParams{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, jsonResParams{
-		User: User{
-			ID:           user.ID,
-			CreatedAt:    user.CreatedAt,
-			UpdatedAt:    user.UpdatedAt,
-			Email:        user.Email,
-			IsChirpyRead: user.IsChirpyRed,
-		},
-		Token:        token,
-		RefreshToken: refreshToken,
-	})
+	cfg.completeLogin(w, r, user)
 }
 
 func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	type jsonResParams struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	refreshToken, err := auth.GetBearerToken(r.Header)
@@ -91,16 +93,64 @@ func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
+	current, err := cfg.db.GetRefreshToken(r.Context(), refreshToken)
 	if err != nil {
 		responseError(w, http.StatusUnauthorized, "Could not get user for refresh token", err)
 		return
 	}
 
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating refresh token", err)
+		return
+	}
+
+	// Claiming the token and marking it replaced is a single update, so two
+	// concurrent refreshes with the same token can't both succeed.
+	_, err = cfg.db.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+		ReplacedBy: sql.NullString{String: newRefreshToken, Valid: true},
+		Token:      current.Token,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		if current.ReplacedBy.Valid || (!current.RevokedAt.Valid && current.ExpiresAt.After(time.Now().UTC())) {
+			// A token that was already rotated is being used again, so
+			// either the client or an attacker holds a stale copy. Revoke
+			// the whole family and make the user log in again.
+			err = cfg.db.RevokeRefreshTokenFamily(r.Context(), current.FamilyID)
+			if err != nil {
+				responseError(w, http.StatusInternalServerError, "Couldn't revoke refresh tokens", err)
+				return
+			}
+		}
+		responseError(w, http.StatusUnauthorized, "Refresh token is no longer valid", nil)
+		return
+	}
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't rotate refresh token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), current.UserID)
+	if err != nil {
+		responseError(w, http.StatusUnauthorized, "Could not get user for refresh token", err)
+		return
+	}
+	if user.SuspendedAt.Valid {
+		responseError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
+	err = cfg.storeRefreshToken(r, newRefreshToken, user.ID, current.FamilyID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't save refresh token", err)
+		return
+	}
+
 	accessToken, err := auth.MakeJWT(
 		user.ID,
-		cfg.seceret,
-		time.Hour,
+		user.Role,
+		cfg.jwtKeys,
+		accessTokenTTL,
 	)
 	if err != nil {
 		responseError(w, http.StatusUnauthorized, "Couldn't validate token", err)
@@ -108,7 +158,8 @@ func (cfg *apiConfig) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	jsonResponse(w, http.StatusOK, jsonResParams{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
 	})
 
 }
@@ -128,3 +179,10 @@ func (cfg *apiConfig) handleRevoke(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// handleJWKS publishes the public signing keys so other services can verify
+// Chirpy tokens without sharing a secret.
+func (cfg *apiConfig) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	jsonResponse(w, http.StatusOK, cfg.jwtKeys.JWKS())
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = time.Hour * 24 * 60
+	mfaTokenTTL     = time.Minute * 5
+)
+
+const maxUserAgentLen = 512
+
+// sessionOnly is the scope for routes that personal access tokens may never
+// use, such as those managing credentials, sessions and tokens.
+const sessionOnly = ""
+
+var (
+	errSessionRequired = errors.New("route requires a login session")
+	errUnknownAPIToken = errors.New("api token is unknown, revoked or expired")
+	errUserSuspended   = errors.New("token owner is suspended")
+	errUnknownUser     = errors.New("token owner no longer exists")
+)
+
+// authenticate returns the ID of the user behind the request's Bearer
+// token. See authenticateUser.
+func (cfg *apiConfig) authenticate(r *http.Request, scope string) (uuid.UUID, error) {
+	user, err := cfg.authenticateUser(r, scope)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return user.ID, nil
+}
+
+// authenticateUser returns the user behind the request's Bearer token.
+// Access tokens carry every scope; a personal access token must have been
+// granted scope, and is refused outright on sessionOnly routes. The user is
+// loaded on every request, so suspensions and role changes apply at once
+// rather than when the token expires.
+func (cfg *apiConfig) authenticateUser(r *http.Request, scope string) (database.User, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	var userID uuid.UUID
+	var apiToken database.ApiToken
+	if auth.IsAPIToken(token) {
+		if scope == sessionOnly {
+			return database.User{}, errSessionRequired
+		}
+
+		apiToken, err = cfg.db.GetAPITokenByHash(r.Context(), auth.HashToken(token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return database.User{}, errUnknownAPIToken
+			}
+			return database.User{}, err
+		}
+
+		if !auth.HasScope(apiToken.Scopes, scope) {
+			return database.User{}, fmt.Errorf("%w: %s", auth.ErrInsufficientScope, scope)
+		}
+		userID = apiToken.UserID
+	} else {
+		userID, err = auth.ValidateJWT(token, cfg.jwtKeys)
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, errUnknownUser
+		}
+		return database.User{}, err
+	}
+	if user.SuspendedAt.Valid {
+		return database.User{}, errUserSuspended
+	}
+
+	if apiToken.ID != uuid.Nil {
+		err = cfg.db.TouchAPIToken(r.Context(), apiToken.ID)
+		if err != nil {
+			log.Printf("Error updating last use of api token %s: %s", apiToken.ID, err)
+		}
+	}
+
+	return user, nil
+}
+
+// completeLogin issues the access token and a refresh token for a new
+// session once every login step has passed, and forgets the account's
+// failed attempts.
+func (cfg *apiConfig) completeLogin(w http.ResponseWriter, r *http.Request, user database.User) {
+	type jsonResParams struct {
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	err := cfg.clearLoginFailures(r.Context(), user.Email)
+	if err != nil {
+		log.Printf("Error clearing failed logins of user %s: %s", user.ID, err)
+	}
+
+	token, err := auth.MakeJWT(user.ID, user.Role, cfg.jwtKeys, accessTokenTTL)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating access JWT", err)
+		return
+	}
+
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating refresh token", err)
+		return
+	}
+
+	err = cfg.storeRefreshToken(r, refreshToken, user.ID, uuid.New())
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't save refresh token", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, jsonResParams{
+		User:         databaseUserToUser(user),
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// rehashPassword stores password under the current hashing parameters. It
+// runs after a successful login, the only time the plain password is known,
+// so failures are logged and the login carries on.
+func (cfg *apiConfig) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	hashedPassword, err := auth.HashPassword(password, cfg.passwordParams)
+	if err != nil {
+		log.Printf("Error rehashing password of user %s: %s", userID, err)
+		return
+	}
+
+	err = cfg.db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		log.Printf("Error saving rehashed password of user %s: %s", userID, err)
+	}
+}
+
+// storeRefreshToken saves a refresh token in familyID along with the device
+// that asked for it. Login starts a new family; every rotation stays in its
+// family so reuse of an old token can revoke all of them.
+func (cfg *apiConfig) storeRefreshToken(r *http.Request, token string, userID, familyID uuid.UUID) error {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+
+	_, err := cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		Token:     token,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+		UserID:    userID,
+		FamilyID:  familyID,
+		UserAgent: userAgent,
+		IpAddress: clientIP(r),
+	})
+	return err
+}
+
+// clientIP returns the address of the connecting peer. Forwarding headers
+// are ignored because clients can set them to anything.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseTokenError answers a request whose access token was refused,
+// telling the client why in the body and in the RFC 6750 WWW-Authenticate
+// header.
+func responseTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInsufficientScope):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		responseError(w, http.StatusForbidden, "Token is missing the required scope", err)
+		return
+	case errors.Is(err, errSessionRequired):
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		responseError(w, http.StatusForbidden, "Personal access tokens can't be used here", err)
+		return
+	case errors.Is(err, errUserSuspended):
+		responseError(w, http.StatusForbidden, "Account suspended", err)
+		return
+	}
+
+	msg := "Couldn't validate token"
+	switch {
+	case errors.Is(err, errUnknownAPIToken), errors.Is(err, errUnknownUser):
+		msg = "Token is invalid or has been revoked"
+	case errors.Is(err, auth.ErrTokenExpired):
+		msg = "Token has expired"
+	case errors.Is(err, auth.ErrTokenNotYetValid):
+		msg = "Token is not valid yet"
+	case errors.Is(err, auth.ErrTokenBadSignature):
+		msg = "Token signature is invalid"
+	case errors.Is(err, auth.ErrTokenWrongIssuer), errors.Is(err, auth.ErrTokenWrongAudience):
+		msg = "Token was not issued for this service"
+	case errors.Is(err, auth.ErrTokenMissingClaim), errors.Is(err, auth.ErrTokenMalformed):
+		msg = "Token is malformed"
+	}
+
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, msg))
+	responseError(w, http.StatusUnauthorized, msg, err)
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,11 +12,23 @@ import (
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID        uuid.UUID    `json:"id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Body      string       `json:"body"`
+	UserID    uuid.UUID    `json:"user_id"`
+	InReplyTo *uuid.UUID   `json:"in_reply_to,omitempty"`
+	DeletedAt *time.Time   `json:"deleted_at,omitempty"`
+	Hidden    bool         `json:"hidden,omitempty"`
+	LikeCount int32        `json:"like_count"`
+	LikedByMe bool         `json:"liked_by_me"`
+	Edited    bool         `json:"edited"`
+	EditedAt  *time.Time   `json:"edited_at,omitempty"`
+	RechirpOf *uuid.UUID   `json:"rechirp_of,omitempty"`
+	Rechirped *Chirp       `json:"rechirped,omitempty"`
+	QuoteOf   *uuid.UUID   `json:"quote_of,omitempty"`
+	Quoted    *Chirp       `json:"quoted,omitempty"`
+	Media     []ChirpMedia `json:"media,omitempty"`
 }
 
 type Chirps struct {
@@ -25,98 +37,151 @@ type Chirps struct {
 
 func (cfg *apiConfig) handleChirp(w http.ResponseWriter, r *http.Request) {
 	type jsonReqParams struct {
-		Body string `json:"body"`
+		Body      string      `json:"body"`
+		InReplyTo *uuid.UUID  `json:"in_reply_to"`
+		QuoteOf   *uuid.UUID  `json:"quote_of"`
+		MediaIDs  []uuid.UUID `json:"media_ids"`
 	}
 
 	type jsonResParams struct {
 		Chirp
 	}
 
-	bearerToken, err := auth.GetBearerToken(r.Header)
+	jwtUserID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
 	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Error getting bearerToken", err)
+		responseTokenError(w, err)
 		return
 	}
 
-	jwtUserID, err := auth.ValidateJWT(bearerToken, cfg.seceret)
-	if err != nil {
-		responseError(w, http.StatusUnauthorized, "Error validating JWT", err)
+	params := jsonReqParams{}
+	if !cfg.chirpLimits.decodeChirpRequest(w, r, &params) {
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := jsonReqParams{}
-	err = decoder.Decode(&params)
-	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirp(r.Context(), *params.InReplyTo)
+		if err != nil || parent.DeletedAt.Valid || parent.HiddenAt.Valid {
+			responseError(w, http.StatusNotFound, "Couldn't find chirp to reply to", err)
+			return
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		if parent.RechirpOf.Valid {
+			inReplyTo = parent.RechirpOf
+		}
+	}
+
+	quoteOf := uuid.NullUUID{}
+	if params.QuoteOf != nil {
+		quoted, err := cfg.db.GetChirp(r.Context(), *params.QuoteOf)
+		if err != nil || quoted.DeletedAt.Valid || quoted.HiddenAt.Valid {
+			responseError(w, http.StatusNotFound, "Couldn't find chirp to quote", err)
+			return
+		}
+		quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+		if quoted.RechirpOf.Valid {
+			quoteOf = quoted.RechirpOf
+		}
+	}
+
+	if len(params.MediaIDs) > maxChirpMedia {
+		responseError(w, http.StatusBadRequest, fmt.Sprintf("A chirp can have at most %d attachments", maxChirpMedia), nil)
 		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   handleValidateChirp(w, params.Body),
-		UserID: jwtUserID,
+	moderated, ok := cfg.handleValidateChirp(w, r, jwtUserID, params.Body)
+	if !ok {
+		return
+	}
+
+	// The chirp only exists if all of its media could be attached.
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		chirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:      moderated.Body,
+			UserID:    jwtUserID,
+			InReplyTo: inReplyTo,
+			QuoteOf:   quoteOf,
+		})
+		if err != nil || len(params.MediaIDs) == 0 {
+			return err
+		}
+
+		attached, err := q.AttachMedia(r.Context(), database.AttachMediaParams{
+			ChirpID: chirp.ID,
+			Ids:     params.MediaIDs,
+			UserID:  jwtUserID,
+		})
+		if err != nil {
+			return err
+		}
+		if attached != int64(len(params.MediaIDs)) {
+			return errMediaNotAttachable
+		}
+		return nil
 	})
+	if errors.Is(err, errMediaNotAttachable) {
+		responseError(w, http.StatusBadRequest, "Media must be your own unattached uploads", nil)
+		return
+	}
 	if err != nil {
 		responseError(w, http.StatusInternalServerError, "Error creating chirp", err)
 		return
 	}
 
-	jsonResponse(w, http.StatusCreated, jsonResParams{
-		Chirp: Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		},
-	})
-}
+	cfg.indexChirpBody(r.Context(), chirp)
+	cfg.flagChirp(r.Context(), chirp.ID, moderated)
 
-func (cfg *apiConfig) handleGetChirps(w http.ResponseWriter, r *http.Request) {
-	dbChirps, err := cfg.db.GetChirps(r.Context())
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: jwtUserID, Valid: true}, chirps)
 	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
 		return
 	}
 
+	jsonResponse(w, http.StatusCreated, jsonResParams{
+		Chirp: chirps[0],
+	})
+}
+
+func (cfg *apiConfig) handleGetChirps(w http.ResponseWriter, r *http.Request) {
 	queryUserID := r.URL.Query().Get("author_id")
-	authorID := uuid.Nil
+	authorID := uuid.NullUUID{}
 	if queryUserID != "" {
-		authorID, err = uuid.Parse(queryUserID)
+		parsedID, err := uuid.Parse(queryUserID)
 		if err != nil {
 			responseError(w, http.StatusBadRequest, "Invallid user ID", err)
 			return
 		}
+		authorID = uuid.NullUUID{UUID: parsedID, Valid: true}
 	}
 
-	sortDirection := "asc"
-	sortDirectionParam := r.URL.Query().Get("sort")
-	if sortDirectionParam == "desc" {
-		sortDirection = "desc"
+	sortDesc := r.URL.Query().Get("sort") == "desc"
+
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
 	}
 
-	chirps := []Chirp{}
-	for _, chirp := range dbChirps {
-		if authorID != uuid.Nil && chirp.UserID != authorID {
-			continue
-		}
+	chirpPage, err := cfg.listChirps(r.Context(), authorID, sortDesc, page)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		return
+	}
 
-		chirps = append(chirps, Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
-		})
+	chirps := []Chirp{}
+	for _, chirp := range chirpPage.Chirps {
+		chirps = append(chirps, databaseChirpToChirp(chirp))
 	}
 
-	sort.Slice(chirps, func(i, j int) bool {
-		if sortDirection == "desc" {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-		}
-		return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-	})
+	err = cfg.hydrateChirps(r.Context(), cfg.optionalViewerID(r), chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
 
+	setPaginationLinks(w, r, chirpPage.NextCursor, chirpPage.PrevCursor)
 	jsonResponse(w, http.StatusOK, chirps)
 }
 
@@ -131,15 +196,17 @@ func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, r *http.Request) {
 	chirp, err := cfg.db.GetChirp(r.Context(), uuidChirpID)
 	if err != nil {
 		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
 	}
 
-	jsonResponse(w, http.StatusOK, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), cfg.optionalViewerID(r), chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, chirps[0])
 }
 
 func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, r *http.Request) {
@@ -150,33 +217,112 @@ func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
 	if err != nil {
-		responseError(w, http.StatusUnauthorized, "Couldn't validate token", err)
+		responseTokenError(w, err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), uuidChirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.seceret)
+	if chirp.UserID != userID {
+		responseError(w, http.StatusForbidden, "Can't delete someone else's chirp", err)
+		return
+	}
+
+	var deletedMedia []database.Medium
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		deletedMedia, err = deleteChirp(r.Context(), q, chirp.ID)
+		return err
+	})
 	if err != nil {
-		responseError(w, http.StatusUnauthorized, "Couldn't validate token", err)
+		responseError(w, http.StatusInternalServerError, "Error deleting chirp", err)
 		return
 	}
+	cfg.deleteMediaBlobs(r.Context(), deletedMedia)
 
-	chirp, err := cfg.db.GetChirp(r.Context(), uuidChirpID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleEditChirp(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Body string `json:"body"`
+	}
+
+	strChirpID := r.PathValue("chirpID")
+	uuidChirpID, err := uuid.Parse(strChirpID)
 	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	params := jsonReqParams{}
+	if !cfg.chirpLimits.decodeChirpRequest(w, r, &params) {
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), uuidChirpID)
+	if err != nil || chirp.DeletedAt.Valid {
 		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
 	}
 
 	if chirp.UserID != userID {
-		responseError(w, http.StatusForbidden, "Can't delete someone else's chirp", err)
+		responseError(w, http.StatusForbidden, "Can't edit someone else's chirp", nil)
+		return
+	}
+
+	if chirp.RechirpOf.Valid {
+		responseError(w, http.StatusBadRequest, "Rechirps can't be edited", nil)
+		return
+	}
+
+	if chirp.HiddenAt.Valid {
+		responseError(w, http.StatusForbidden, "Chirp has been hidden by a moderator", nil)
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), chirp.ID)
+	moderated, ok := cfg.handleValidateChirp(w, r, userID, params.Body)
+	if !ok {
+		return
+	}
+
+	// The tags always match the body they were taken from.
+	var edited database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		edited, err = q.EditChirp(r.Context(), database.EditChirpParams{
+			ID:   chirp.ID,
+			Body: moderated.Body,
+		})
+		if err != nil {
+			return err
+		}
+
+		return indexChirpTags(r.Context(), q, edited)
+	})
 	if err != nil {
-		responseError(w, http.StatusInternalServerError, "Error deleting chirp", err)
+		responseError(w, http.StatusInternalServerError, "Error editing chirp", err)
 		return
 	}
+	cfg.recordMentions(r.Context(), edited)
+	cfg.flagChirp(r.Context(), edited.ID, moderated)
 
-	w.WriteHeader(http.StatusNoContent)
+	chirps := []Chirp{databaseChirpToChirp(edited)}
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, chirps[0])
 }
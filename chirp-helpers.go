@@ -1,39 +1,447 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/chirptext"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/moderation"
 )
 
-func cleanBody(reqBody string, blWords map[string]struct{}) string {
-	reqWords := strings.Split(reqBody, " ")
+const maxTagLen = 100
+
+// chirpRequestOverhead leaves room in a chirp request for everything but
+// the body.
+const chirpRequestOverhead = 4 << 10
+
+var hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_#])#([\p{L}\p{N}_]+)`)
+
+type chirpPage struct {
+	Chirps     []database.Chirp
+	NextCursor string
+	PrevCursor string
+}
+
+// deleteChirp removes a chirp along with its derived data using q, so it
+// can run inside a transaction. Chirps that are replied to or quoted are
+// tombstoned instead of deleted so threads keep their shape and quotes have
+// something to point at. Rechirps of a tombstone have nothing left to
+// boost, so they go. The removed media rows are returned; their blobs are
+// for the caller to delete once the rows are really gone.
+func deleteChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID) ([]database.Medium, error) {
+	isReferenced, err := q.ChirpHasReferences(ctx, chirpID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isReferenced {
+		err = q.TombstoneChirp(ctx, chirpID)
+		if err == nil {
+			err = q.DeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirpID, Valid: true})
+		}
+		if err == nil {
+			err = q.DeleteChirpTags(ctx, chirpID)
+		}
+		if err == nil {
+			err = q.DeleteChirpRevisions(ctx, chirpID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := q.DeleteChirpMedia(ctx, uuid.NullUUID{UUID: chirpID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
 
-	for i, word := range reqWords {
-		lowerCaseWord := strings.ToLower(word)
-		if _, ok := blWords[lowerCaseWord]; ok {
-			reqWords[i] = "****"
+	if !isReferenced {
+		err = q.DeleteChirp(ctx, chirpID)
+		if err != nil {
+			return nil, err
 		}
 	}
-	cleaned := strings.Join(reqWords, " ")
 
-	return cleaned
+	return deleted, nil
+}
+
+// indexChirpBody records the hashtags and mentions found in a new chirp's
+// body. Both are derived data, so failing to index them is logged rather
+// than failing a chirp that has already been stored.
+func (cfg *apiConfig) indexChirpBody(ctx context.Context, chirp database.Chirp) {
+	err := indexChirpTags(ctx, cfg.db, chirp)
+	if err != nil {
+		log.Printf("Error indexing tags for chirp %s: %s", chirp.ID, err)
+	}
+
+	cfg.recordMentions(ctx, chirp)
+}
+
+// indexChirpTags replaces a chirp's tags with the hashtags in its body using
+// q, so it can run inside a transaction. Tags are dated by the chirp rather
+// than by the indexing, so editing an old chirp doesn't make it trend again.
+func indexChirpTags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	err := q.DeleteChirpTags(ctx, chirp.ID)
+	if err != nil {
+		return err
+	}
+
+	tags := extractHashtags(chirp.Body)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return q.AddChirpTags(ctx, database.AddChirpTagsParams{
+		ChirpID:   chirp.ID,
+		Tags:      tags,
+		CreatedAt: chirp.CreatedAt,
+	})
 }
 
-func getBlackListWords() map[string]struct{} {
-	return map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+// recordMentions notifies the users mentioned in a chirp's body. Failures
+// are logged, since the chirp itself is already stored.
+func (cfg *apiConfig) recordMentions(ctx context.Context, chirp database.Chirp) {
+	usernames := extractMentions(chirp.Body)
+	if len(usernames) == 0 {
+		return
+	}
+
+	err := cfg.db.CreateMentions(ctx, database.CreateMentionsParams{
+		ChirpID:   chirp.ID,
+		Usernames: usernames,
+		ActorID:   chirp.UserID,
+	})
+	if err != nil {
+		log.Printf("Error recording mentions for chirp %s: %s", chirp.ID, err)
 	}
 }
 
-func handleValidateChirp(w http.ResponseWriter, body string) string {
-	if len(body) > maxChirpLen {
-		responseError(w, http.StatusBadRequest, "Chirp too long", nil)
-		return ""
+// extractHashtags returns the distinct #hashtags in body, lowercased and
+// without the leading #.
+func extractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]struct{}{}
+
+	for _, match := range hashtagRegexp.FindAllStringSubmatch(body, -1) {
+		tag := strings.ToLower(match[1])
+		if len(tag) > maxTagLen {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
 	}
 
-	clean := cleanBody(body, getBlackListWords())
+	return tags
+}
+
+// chirpLimits holds the maximum chirp length for each account tier.
+type chirpLimits struct {
+	Standard int
+	Red      int
+}
+
+func (limits chirpLimits) forUser(user database.User) int {
+	if user.IsChirpyRed {
+		return limits.Red
+	}
+	return limits.Standard
+}
+
+// maxRequestBytes caps a chirp create or edit request: the longest body
+// any tier allows, six times over in case every byte is sent as a \u
+// escape, plus the other fields.
+func (limits chirpLimits) maxRequestBytes() int64 {
+	return int64(6*chirptext.MaxBytesPerChar*max(limits.Standard, limits.Red)) + chirpRequestOverhead
+}
+
+// decodeChirpRequest decodes a chirp create or edit request into params,
+// capped at maxRequestBytes, telling oversized bodies apart from malformed
+// ones.
+func (limits chirpLimits) decodeChirpRequest(w http.ResponseWriter, r *http.Request, params interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, limits.maxRequestBytes())
+	err := json.NewDecoder(r.Body).Decode(params)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		responseError(w, http.StatusRequestEntityTooLarge, "Chirp too large", err)
+		return false
+	}
+	responseError(w, http.StatusBadRequest, "Error decoding parameters", err)
+	return false
+}
+
+// validateChirpLength responds with the limit and the body's length when
+// body is longer than limit.
+func validateChirpLength(w http.ResponseWriter, body string, limit int) bool {
+	length := chirptext.Length(body)
+	if length <= limit {
+		return true
+	}
+
+	type chirpTooLongError struct {
+		Error  string `json:"error"`
+		Limit  int    `json:"limit"`
+		Length int    `json:"length"`
+	}
+
+	jsonResponse(w, http.StatusBadRequest, chirpTooLongError{
+		Error:  "Chirp too long",
+		Limit:  limit,
+		Length: length,
+	})
+	return false
+}
+
+func (cfg *apiConfig) handleValidateChirp(w http.ResponseWriter, r *http.Request, userID uuid.UUID, body string) (moderation.Result, bool) {
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting user", err)
+		return moderation.Result{}, false
+	}
+	if user.SuspendedAt.Valid {
+		responseError(w, http.StatusForbidden, "Account suspended", nil)
+		return moderation.Result{}, false
+	}
+
+	if !validateChirpLength(w, body, cfg.chirpLimits.forUser(user)) {
+		return moderation.Result{}, false
+	}
+
+	filter, err := cfg.wordFilter.Filter(r.Context())
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading moderation words", err)
+		return moderation.Result{}, false
+	}
+
+	result := filter.Check(body)
+	if result.Rejected {
+		responseError(w, http.StatusBadRequest, "Chirp contains a banned word", nil)
+		return moderation.Result{}, false
+	}
+
+	return result, true
+}
+
+func databaseChirpToChirp(chirp database.Chirp) Chirp {
+	result := Chirp{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		LikeCount: chirp.LikeCount,
+	}
+	if chirp.InReplyTo.Valid {
+		result.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	if chirp.DeletedAt.Valid {
+		result.DeletedAt = &chirp.DeletedAt.Time
+	}
+	if chirp.HiddenAt.Valid {
+		result.Hidden = true
+		result.Body = ""
+	}
+	if chirp.EditedAt.Valid {
+		result.Edited = true
+		result.EditedAt = &chirp.EditedAt.Time
+	}
+	if chirp.RechirpOf.Valid {
+		result.RechirpOf = &chirp.RechirpOf.UUID
+	}
+	if chirp.QuoteOf.Valid {
+		result.QuoteOf = &chirp.QuoteOf.UUID
+	}
+
+	return result
+}
+
+// optionalViewerID returns the caller's user ID when the request carries a
+// valid access token or a token with chirps:read. Public endpoints use it to
+// personalize responses, so a missing or bad token just means an anonymous
+// viewer.
+func (cfg *apiConfig) optionalViewerID(r *http.Request) uuid.NullUUID {
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsRead)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// hydrateChirps fills in everything a chirp payload needs beyond its own row:
+// the rechirped or quoted chirp it references, attached media and the
+// viewer's likes.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []Chirp) error {
+	referenced, err := cfg.embedReferencedChirps(ctx, chirps)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range [][]Chirp{chirps, referenced} {
+		err = cfg.attachChirpMedia(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		err = cfg.markLikedChirps(ctx, viewerID, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// embedReferencedChirps loads the chirps that rechirps and quotes point at
+// and links them into the payloads. Only one level is embedded. The loaded
+// chirps are returned so the caller can decorate them further.
+func (cfg *apiConfig) embedReferencedChirps(ctx context.Context, chirps []Chirp) ([]Chirp, error) {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RechirpOf != nil {
+			ids = append(ids, *chirp.RechirpOf)
+		}
+		if chirp.QuoteOf != nil {
+			ids = append(ids, *chirp.QuoteOf)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	dbReferenced, err := cfg.db.GetChirpsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make([]Chirp, 0, len(dbReferenced))
+	byID := map[uuid.UUID]int{}
+	for i, chirp := range dbReferenced {
+		referenced = append(referenced, databaseChirpToChirp(chirp))
+		byID[chirp.ID] = i
+	}
+
+	for i := range chirps {
+		if chirps[i].RechirpOf != nil {
+			if idx, ok := byID[*chirps[i].RechirpOf]; ok {
+				chirps[i].Rechirped = &referenced[idx]
+			}
+		}
+		if chirps[i].QuoteOf != nil {
+			if idx, ok := byID[*chirps[i].QuoteOf]; ok {
+				chirps[i].Quoted = &referenced[idx]
+			}
+		}
+	}
+
+	return referenced, nil
+}
+
+// markLikedChirps sets LikedByMe on every chirp the viewer has liked using a
+// single lookup for the whole page.
+func (cfg *apiConfig) markLikedChirps(ctx context.Context, viewerID uuid.NullUUID, chirps []Chirp) error {
+	if !viewerID.Valid || len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	liked := map[uuid.UUID]struct{}{}
+	for _, id := range likedIDs {
+		liked[id] = struct{}{}
+	}
+	for i := range chirps {
+		if _, ok := liked[chirps[i].ID]; ok {
+			chirps[i].LikedByMe = true
+		}
+	}
+
+	return nil
+}
+
+// listChirps fetches one page of chirps ordered by (created_at, id). A before
+// cursor is served by walking the index in the opposite direction and
+// reversing the result, so both directions share the same two queries.
+func (cfg *apiConfig) listChirps(ctx context.Context, authorID uuid.NullUUID, desc bool, page pageParams) (chirpPage, error) {
+	cursor := page.After
+	backwards := false
+	if page.Before != nil {
+		cursor = page.Before
+		backwards = true
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(cursor)
+
+	var dbChirps []database.Chirp
+	var err error
+	if desc != backwards {
+		dbChirps, err = cfg.db.ListChirpsDesc(ctx, database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.Limit + 1,
+		})
+	} else {
+		dbChirps, err = cfg.db.ListChirpsAsc(ctx, database.ListChirpsAscParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.Limit + 1,
+		})
+	}
+	if err != nil {
+		return chirpPage{}, err
+	}
+
+	hasMore := len(dbChirps) > int(page.Limit)
+	if hasMore {
+		dbChirps = dbChirps[:page.Limit]
+	}
+	if backwards {
+		slices.Reverse(dbChirps)
+	}
+
+	result := chirpPage{
+		Chirps: dbChirps,
+	}
+	if len(dbChirps) == 0 {
+		return result, nil
+	}
+
+	first := dbChirps[0]
+	last := dbChirps[len(dbChirps)-1]
+	if (!backwards && hasMore) || (backwards && cursor != nil) {
+		result.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	if (backwards && hasMore) || (!backwards && cursor != nil) {
+		result.PrevCursor = encodeCursor(first.CreatedAt, first.ID)
+	}
 
-	return clean
+	return result, nil
 }
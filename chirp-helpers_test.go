@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateChirpLength(t *testing.T) {
+	limits := chirpLimits{Standard: 140, Red: 280}
+
+	flag := "\U0001F1FA\U0001F1F8"
+	family := "\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466"
+	longURL := "https://example.com/" + strings.Repeat("x", 1000)
+
+	tests := []struct {
+		name   string
+		body   string
+		length int
+		ok     bool
+	}{
+		{"flags", strings.Repeat(flag, 140), 140, true},
+		{"zwj emoji", strings.Repeat(family, 140), 140, true},
+		{"long url", "look " + longURL, 28, true},
+		{"many long urls", strings.Repeat(longURL+" ", 5), 120, true},
+		{"too many flags", strings.Repeat(flag, 141), 141, false},
+		{"too many urls", strings.Repeat(longURL+" ", 6), 144, false},
+	}
+
+	for _, test := range tests {
+		reqBody, err := json.Marshal(map[string]string{"body": test.body})
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", test.name, err)
+		}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/chirps", strings.NewReader(string(reqBody)))
+		params := struct {
+			Body string `json:"body"`
+		}{}
+		if !limits.decodeChirpRequest(w, r, &params) {
+			t.Errorf("%s: request rejected with %d: %s", test.name, w.Code, w.Body)
+			continue
+		}
+
+		ok := validateChirpLength(w, params.Body, limits.Standard)
+		if ok != test.ok {
+			t.Errorf("%s: validateChirpLength = %v, expected %v", test.name, ok, test.ok)
+			continue
+		}
+		if ok {
+			continue
+		}
+
+		resBody := struct {
+			Error  string `json:"error"`
+			Limit  int    `json:"limit"`
+			Length int    `json:"length"`
+		}{}
+		err = json.NewDecoder(w.Body).Decode(&resBody)
+		if err != nil {
+			t.Fatalf("%s: decode response: %v", test.name, err)
+		}
+		if w.Code != http.StatusBadRequest || resBody.Limit != limits.Standard || resBody.Length != test.length {
+			t.Errorf("%s: got %d %+v, expected 400 with limit %d and length %d", test.name, w.Code, resBody, limits.Standard, test.length)
+		}
+	}
+}
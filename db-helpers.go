@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+// withTx runs fn against a transaction, committing it if fn returns nil
+// and rolling it back otherwise.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(cfg.db.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+// handleVerifyEmail confirms the address a verification token was sent to,
+// making it the account's email if it was a pending change.
+func (cfg *apiConfig) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Token string `json:"token"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Error decoding parameters", err)
+		return
+	}
+
+	verification, err := cfg.db.UseEmailVerificationToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseError(w, http.StatusBadRequest, "Invalid or expired verification token", nil)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Error using verification token", err)
+		return
+	}
+
+	user, err := cfg.db.ConfirmUserEmail(r.Context(), database.ConfirmUserEmailParams{
+		Email: verification.Email,
+		ID:    verification.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The user has since asked for a different email.
+			responseError(w, http.StatusBadRequest, "Invalid or expired verification token", nil)
+			return
+		}
+		if isUniqueViolation(err) {
+			responseError(w, http.StatusConflict, "Email already registered", err)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Couldn't confirm email", err)
+		return
+	}
+
+	if admin, ok := cfg.grantBootstrapAdmin(r.Context(), user.Email); ok {
+		user = admin
+	}
+
+	if !user.PendingEmail.Valid {
+		err = cfg.db.InvalidateEmailVerificationTokens(r.Context(), user.ID)
+		if err != nil {
+			log.Printf("Error invalidating verification tokens of user %s: %s", user.ID, err)
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, databaseUserToUser(user))
+}
+
+// handleResendEmailVerification sends a new token for the pending email, or
+// for the current one if it was never confirmed.
+func (cfg *apiConfig) handleResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting user", err)
+		return
+	}
+
+	email := user.PendingEmail.String
+	if !user.PendingEmail.Valid {
+		if user.EmailVerifiedAt.Valid {
+			responseError(w, http.StatusConflict, "Email already verified", nil)
+			return
+		}
+		email = user.Email
+	}
+
+	if !cfg.reserveEmailVerificationAttempt(w, r, uuid.NullUUID{UUID: user.ID, Valid: true}, email) {
+		return
+	}
+
+	go cfg.sendEmailVerification(user.ID, email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/mail"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	// emailVerificationSendTimeout bounds the background work behind a
+	// verification email, mail delivery included.
+	emailVerificationSendTimeout = time.Minute
+)
+
+// sendEmailVerification mails a token that confirms userID owns email. The
+// address is stored with the token, so confirming it also completes a
+// pending email change. It runs after the request has been answered, so
+// failures are only logged.
+func (cfg *apiConfig) sendEmailVerification(userID uuid.UUID, email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), emailVerificationSendTimeout)
+	defer cancel()
+
+	err := cfg.mailEmailVerification(ctx, userID, email)
+	if err != nil {
+		log.Printf("Error sending verification email to user %s: %s", userID, err)
+	}
+}
+
+func (cfg *apiConfig) mailEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	err = cfg.db.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().UTC().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Confirm your email for Chirpy",
+		Body: fmt.Sprintf("Please confirm this email address for your Chirpy account.\n\n"+
+			"Your verification token is:\n\n%s\n\n"+
+			"It expires in %d hours. If you didn't sign up for Chirpy, you can ignore this email.\n",
+			token, int(emailVerificationTTL.Hours())),
+	})
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleFollowUser(w http.ResponseWriter, r *http.Request) {
+	followedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeProfileWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	if followedID == userID {
+		responseError(w, http.StatusBadRequest, "Can't follow yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), followedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseError(w, http.StatusNotFound, "Couldn't find user", err)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	err = cfg.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FollowedID: followedID,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeProfileWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	err = cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FollowedID: followedID,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parseForwardPageParams(r)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(page.After)
+	followers, err := cfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't get followers", err)
+		return
+	}
+
+	users := []PublicUser{}
+	nextCursor := ""
+	for i, follower := range followers {
+		if i == int(page.Limit) {
+			last := followers[i-1]
+			nextCursor = encodeCursor(last.FollowedAt, last.ID)
+			break
+		}
+		users = append(users, PublicUser{
+			ID:        follower.ID,
+			CreatedAt: follower.CreatedAt,
+			Username:  follower.Username.String,
+		})
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, users)
+}
+
+func (cfg *apiConfig) handleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	page, err := parseForwardPageParams(r)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(page.After)
+	following, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't get followed users", err)
+		return
+	}
+
+	users := []PublicUser{}
+	nextCursor := ""
+	for i, followed := range following {
+		if i == int(page.Limit) {
+			last := following[i-1]
+			nextCursor = encodeCursor(last.FollowedAt, last.ID)
+			break
+		}
+		users = append(users, PublicUser{
+			ID:        followed.ID,
+			CreatedAt: followed.CreatedAt,
+			Username:  followed.Username.String,
+		})
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, users)
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// APITokenPrefix marks personal access tokens so they can be told apart
+// from JWTs in the same Authorization header.
+const APITokenPrefix = "chirpy_pat_"
+
+// apiTokenDisplayLen is how much of a token is kept in clear text so users
+// can recognize it in listings.
+const apiTokenDisplayLen = len(APITokenPrefix) + 6
+
+const (
+	ScopeChirpsRead   = "chirps:read"
+	ScopeChirpsWrite  = "chirps:write"
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+)
+
+var validScopes = map[string]bool{
+	ScopeChirpsRead:   true,
+	ScopeChirpsWrite:  true,
+	ScopeProfileRead:  true,
+	ScopeProfileWrite: true,
+}
+
+var ErrInsufficientScope = errors.New("token is missing the required scope")
+
+func ValidScope(scope string) bool {
+	return validScopes[scope]
+}
+
+// HasScope reports whether required is among the granted scopes.
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeAPIToken returns a new personal access token and the short prefix
+// that identifies it. Only HashToken(token) should be stored.
+func MakeAPIToken() (token, display string, err error) {
+	ranData := make([]byte, 32)
+	_, err = rand.Read(ranData)
+	if err != nil {
+		return "", "", err
+	}
+
+	token = APITokenPrefix + hex.EncodeToString(ranData)
+	return token, token[:apiTokenDisplayLen], nil
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
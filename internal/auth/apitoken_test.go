@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMakeAPIToken(t *testing.T) {
+	token, display, err := MakeAPIToken()
+	if err != nil {
+		t.Fatalf("MakeAPIToken: %s", err)
+	}
+
+	if !IsAPIToken(token) {
+		t.Errorf("token %q is missing the %q prefix", token, APITokenPrefix)
+	}
+	if !strings.HasPrefix(token, display) || len(display) >= len(token) {
+		t.Errorf("display %q should be a short prefix of the token", display)
+	}
+	if HashToken(token) == HashToken(token+"x") {
+		t.Error("different tokens hashed to the same value")
+	}
+
+	other, _, err := MakeAPIToken()
+	if err != nil {
+		t.Fatalf("MakeAPIToken: %s", err)
+	}
+	if other == token {
+		t.Error("MakeAPIToken returned the same token twice")
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	granted := []string{ScopeChirpsRead, ScopeProfileWrite}
+
+	tests := []struct {
+		required string
+		expected bool
+	}{
+		{ScopeChirpsRead, true},
+		{ScopeProfileWrite, true},
+		{ScopeChirpsWrite, false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := HasScope(granted, test.required); got != test.expected {
+			t.Errorf("HasScope(%v, %q) = %v, expected %v", granted, test.required, got, test.expected)
+		}
+	}
+}
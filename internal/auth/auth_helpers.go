@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,49 +12,98 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hashedByt, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedByt), err
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
 }
 
-func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+type Claims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role,omitempty"`
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
 
-	return token.SignedString([]byte(tokenSecret))
+// HasRole reports whether role grants at least the access of required.
+// Roles are ordered user < moderator < admin; unknown roles grant nothing.
+func HasRole(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
+func MakeJWT(userID uuid.UUID, role string, keys *KeyRing, expiresIn time.Duration) (string, error) {
+	return keys.Sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Audience:  jwt.ClaimStrings{AccessTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+		Role: role,
 	})
+}
+
+func ValidateJWT(tokenString string, keys *KeyRing) (uuid.UUID, error) {
+	userID, _, err := ValidateJWTRole(tokenString, keys)
+	return userID, err
+}
+
+// ValidateJWTRole validates the token like ValidateJWT and also returns the
+// role claim. Tokens issued before roles existed carry no role claim and are
+// treated as RoleUser.
+func ValidateJWTRole(tokenString string, keys *KeyRing) (uuid.UUID, string, error) {
+	claims, err := ParseJWT(tokenString, keys, AccessTokenOptions)
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, "", err
+	}
+
+	uuidUserID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.UUID{}, "", fmt.Errorf("%w: %w", ErrTokenMalformed, err)
 	}
 
-	if !token.Valid {
-		return uuid.UUID{}, fmt.Errorf("invalid token")
+	role := claims.Role
+	if role == "" {
+		role = RoleUser
 	}
 
-	strUserID, err := token.Claims.GetSubject()
+	return uuidUserID, role, nil
+}
+
+// MakeMFAToken returns a challenge token proving the password step of a
+// login passed. It only works with ValidateMFAToken.
+func MakeMFAToken(userID uuid.UUID, keys *KeyRing, expiresIn time.Duration) (string, error) {
+	return keys.Sign(jwt.RegisteredClaims{
+		Issuer:    Issuer,
+		Audience:  jwt.ClaimStrings{mfaTokenAudience},
+		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+		Subject:   userID.String(),
+	})
+}
+
+func ValidateMFAToken(tokenString string, keys *KeyRing) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, keys, mfaTokenOptions)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
 
-	uuidUserID, err := uuid.Parse(strUserID)
+	uuidUserID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, fmt.Errorf("%w: %w", ErrTokenMalformed, err)
 	}
 
 	return uuidUserID, nil
@@ -106,3 +156,11 @@ func MakeRefreshToken() (string, error) {
 
 	return hex.EncodeToString(ranData), nil
 }
+
+// HashToken hashes a random token such as an API or password reset token
+// for storage and lookup. The tokens carry 256 bits of randomness, so a fast
+// unsalted hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -10,7 +10,7 @@ import (
 
 func TestHashed(t *testing.T) {
 	password := "testingpassword"
-	hashed, err := HashPassword(password)
+	hashed, err := HashPassword(password, testArgon2Params)
 	if err != nil {
 		t.Errorf("HashPassword(%s) returned an err: %v", password, err)
 	}
@@ -22,7 +22,7 @@ func TestHashed(t *testing.T) {
 
 func TestCheckPasswordHash(t *testing.T) {
 	password := "testingpassword"
-	hashed, _ := HashPassword(password)
+	hashed, _ := HashPassword(password, testArgon2Params)
 
 	err := CheckPasswordHash(password, hashed)
 	if err != nil {
@@ -39,38 +39,106 @@ func TestCheckPasswordHash(t *testing.T) {
 
 func TestJWT(t *testing.T) {
 	userID, _ := uuid.Parse("a0de6ac2-0b73-432c-a5db-b96d6451251f")
-	tokenSecret := "test"
-	wrongSecret := "wrong"
+	keys := testKeyRing(t, "test")
+	wrongKeys := testKeyRing(t, "test")
 
-	JWT, err := MakeJWT(userID, tokenSecret, time.Second*30)
+	JWT, err := MakeJWT(userID, RoleModerator, keys, time.Second*30)
 	if err != nil {
 		t.Errorf("MakeJWT returned a non nil error: %v", err)
 	}
 
-	validatedUser, err := ValidateJWT(JWT, tokenSecret)
+	validatedUser, err := ValidateJWT(JWT, keys)
 	if err != nil {
-		t.Errorf("ValidateJWT(JWT, %s) returning error when trying to validate JWT: %v", tokenSecret, err)
+		t.Errorf("ValidateJWT(JWT, keys) returning error when trying to validate JWT: %v", err)
 	}
 
 	if validatedUser != userID {
 		t.Errorf("validatedUser: %s, is not matching the provided userID: %s", validatedUser, userID)
 	}
 
-	_, err = ValidateJWT(JWT, wrongSecret)
+	_, role, err := ValidateJWTRole(JWT, keys)
+	if err != nil || role != RoleModerator {
+		t.Errorf("ValidateJWTRole(JWT, keys) returned role %q, err %v; expected %q", role, err, RoleModerator)
+	}
+
+	_, err = ValidateJWT(JWT, wrongKeys)
 	if err == nil {
-		t.Errorf("ValidateJWT(JWT, %s) validated token when it should of failed wrong secret: %v", wrongSecret, err)
+		t.Error("ValidateJWT(JWT, wrongKeys) validated token when it should of failed: different key with the same kid")
 	}
 
-	expiredJWT, err := MakeJWT(userID, tokenSecret, time.Second*0)
+	expiredJWT, err := MakeJWT(userID, RoleModerator, keys, time.Second*0)
 	if err != nil {
 		t.Errorf("MakeJWT returned a non nil error: %v", err)
 	}
 
-	_, err = ValidateJWT(expiredJWT, tokenSecret)
+	_, err = ValidateJWT(expiredJWT, keys)
 	if err == nil {
-		t.Errorf("ValidateJWT(expiredJWT, %s) validated token when it should of failed: expired", tokenSecret)
+		t.Error("ValidateJWT(expiredJWT, keys) validated token when it should of failed: expired")
+	}
+
+}
+
+func TestMFATokenIsNotAnAccessToken(t *testing.T) {
+	userID := uuid.New()
+	keys := testKeyRing(t, "test")
+
+	mfaToken, err := MakeMFAToken(userID, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeMFAToken returned an err: %v", err)
 	}
 
+	validatedUser, err := ValidateMFAToken(mfaToken, keys)
+	if err != nil || validatedUser != userID {
+		t.Errorf("ValidateMFAToken = %s, %v; expected %s", validatedUser, err, userID)
+	}
+
+	_, err = ValidateJWT(mfaToken, keys)
+	if err == nil {
+		t.Error("ValidateJWT accepted an MFA challenge token")
+	}
+
+	accessToken, _ := MakeJWT(userID, RoleUser, keys, time.Minute)
+	_, err = ValidateMFAToken(accessToken, keys)
+	if err == nil {
+		t.Error("ValidateMFAToken accepted an access token")
+	}
+}
+
+func testKeyRing(t *testing.T, kid string) *KeyRing {
+	t.Helper()
+
+	key, err := GenerateEd25519Key(kid)
+	if err != nil {
+		t.Fatalf("GenerateEd25519Key returned an err: %v", err)
+	}
+	ring, err := NewKeyRing(kid, key)
+	if err != nil {
+		t.Fatalf("NewKeyRing returned an err: %v", err)
+	}
+
+	return ring
+}
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		expected bool
+	}{
+		{RoleUser, RoleUser, true},
+		{RoleUser, RoleModerator, false},
+		{RoleModerator, RoleModerator, true},
+		{RoleModerator, RoleAdmin, false},
+		{RoleAdmin, RoleModerator, true},
+		{"", RoleUser, false},
+		{"root", RoleUser, false},
+	}
+
+	for _, test := range tests {
+		if got := HasRole(test.role, test.required); got != test.expected {
+			t.Errorf("HasRole(%q, %q) = %v, expected %v", test.role, test.required, got, test.expected)
+		}
+	}
 }
 
 func TestBearerToken(t *testing.T) {
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey is one key in a KeyRing. Private is nil for keys that are only
+// kept to verify tokens signed before they were retired.
+type SigningKey struct {
+	ID      string
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+func (key SigningKey) method() (jwt.SigningMethod, error) {
+	switch pub := key.Public.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key %s is smaller than %d bits", key.ID, minRSAKeyBits)
+		}
+		return jwt.SigningMethodRS256, nil
+	}
+	return nil, fmt.Errorf("key %s has an unsupported type %T", key.ID, key.Public)
+}
+
+// KeyRing signs tokens with its active key and verifies tokens signed by any
+// key it holds, picked by the token's kid header. Rotating means adding a
+// new key, making it active, and dropping the old one once every token it
+// signed has expired.
+type KeyRing struct {
+	active  string
+	keys    map[string]SigningKey
+	methods map[string]jwt.SigningMethod
+}
+
+func NewKeyRing(activeID string, keys ...SigningKey) (*KeyRing, error) {
+	ring := &KeyRing{
+		active:  activeID,
+		keys:    map[string]SigningKey{},
+		methods: map[string]jwt.SigningMethod{},
+	}
+
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("signing keys need an ID")
+		}
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %s", key.ID)
+		}
+		method, err := key.method()
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[key.ID] = key
+		ring.methods[key.ID] = method
+	}
+
+	active, ok := ring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %s is not in the key ring", activeID)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("active key %s has no private key", activeID)
+	}
+
+	return ring, nil
+}
+
+// GenerateEd25519Key returns a new random Ed25519 key.
+func GenerateEd25519Key(id string) (SigningKey, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	return SigningKey{ID: id, Private: private, Public: public}, nil
+}
+
+// LoadKeyRing reads every .pem file in dir as a key named after the file.
+// Files may hold a PKCS#8 or PKCS#1 private key, or a PKIX public key for a
+// retired key that only verifies.
+func LoadKeyRing(dir, activeID string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []SigningKey{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parsePEMKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeyRing(activeID, keys...)
+}
+
+func parsePEMKey(id string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return SigningKey{}, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		return SigningKey{ID: id, Private: signer, Public: signer.Public()}, nil
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		return SigningKey{ID: id, Private: parsed, Public: parsed.Public()}, nil
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		return SigningKey{ID: id, Public: parsed}, nil
+	}
+
+	return SigningKey{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// Sign signs claims with the active key and sets the kid header.
+func (ring *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	key := ring.keys[ring.active]
+	token := jwt.NewWithClaims(ring.methods[key.ID], claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// keyFunc finds the verification key named by the token's kid and refuses
+// tokens whose algorithm doesn't match that key.
+func (ring *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ring.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != ring.methods[kid].Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+
+	return key.Public, nil
+}
+
+// JWK is a public key in RFC 7517 JSON Web Key format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key in the ring, sorted by ID.
+func (ring *KeyRing) JWKS() JWKSet {
+	ids := make([]string, 0, len(ring.keys))
+	for id := range ring.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, id := range ids {
+		jwk := JWK{
+			KeyID:     id,
+			Use:       "sig",
+			Algorithm: ring.methods[id].Alg(),
+		}
+
+		switch pub := ring.keys[id].Public.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestKeyRingRotation(t *testing.T) {
+	oldKey, _ := GenerateEd25519Key("2024-01")
+	newKey, _ := GenerateEd25519Key("2024-02")
+	userID := uuid.New()
+
+	oldRing, err := NewKeyRing(oldKey.ID, oldKey)
+	if err != nil {
+		t.Fatalf("NewKeyRing returned an err: %v", err)
+	}
+	oldToken, _ := MakeJWT(userID, RoleUser, oldRing, time.Minute)
+
+	// The retired key keeps only its public half.
+	retired := SigningKey{ID: oldKey.ID, Public: oldKey.Public}
+	ring, err := NewKeyRing(newKey.ID, newKey, retired)
+	if err != nil {
+		t.Fatalf("NewKeyRing returned an err: %v", err)
+	}
+
+	validatedUser, err := ValidateJWT(oldToken, ring)
+	if err != nil || validatedUser != userID {
+		t.Errorf("ValidateJWT of a token signed by a retired key = %s, %v", validatedUser, err)
+	}
+
+	newToken, _ := MakeJWT(userID, RoleUser, ring, time.Minute)
+	_, err = ValidateJWT(newToken, oldRing)
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("ValidateJWT with a ring missing the kid returned %v, expected ErrUnknownKey", err)
+	}
+
+	if len(ring.JWKS().Keys) != 2 {
+		t.Errorf("JWKS returned %d keys, expected 2", len(ring.JWKS().Keys))
+	}
+
+	_, err = NewKeyRing(retired.ID, retired)
+	if err == nil {
+		t.Error("NewKeyRing accepted an active key without a private key")
+	}
+}
+
+func TestLoadKeyRingRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey returned an err: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(private)
+
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	err = os.WriteFile(filepath.Join(dir, "rsa-1.pem"), data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ring, err := LoadKeyRing(dir, "rsa-1")
+	if err != nil {
+		t.Fatalf("LoadKeyRing returned an err: %v", err)
+	}
+
+	userID := uuid.New()
+	token, _ := MakeJWT(userID, RoleUser, ring, time.Minute)
+	validatedUser, err := ValidateJWT(token, ring)
+	if err != nil || validatedUser != userID {
+		t.Errorf("ValidateJWT of an RS256 token = %s, %v", validatedUser, err)
+	}
+
+	jwks := ring.JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Algorithm != "RS256" || jwks.Keys[0].KeyType != "RSA" {
+		t.Errorf("JWKS returned %+v, expected one RS256 key", jwks.Keys)
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keep the tests fast; they are far too cheap for real use.
+var testArgon2Params = Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHashPasswordPHCFormat(t *testing.T) {
+	hash, err := HashPassword("correct horse", testArgon2Params)
+	if err != nil {
+		t.Fatalf("HashPassword: %s", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("unexpected PHC string %q", hash)
+	}
+	if NeedsRehash(hash, testArgon2Params) {
+		t.Error("fresh hash should not need a rehash")
+	}
+	if !NeedsRehash(hash, DefaultArgon2Params) {
+		t.Error("hash with other parameters should need a rehash")
+	}
+}
+
+func TestCheckLegacyBcryptHash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %s", err)
+	}
+
+	err = CheckPasswordHash("hunter2", string(legacy))
+	if err != nil {
+		t.Errorf("legacy bcrypt hash should match: %s", err)
+	}
+	err = CheckPasswordHash("hunter3", string(legacy))
+	if !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("expected ErrPasswordMismatch, got %v", err)
+	}
+	if !NeedsRehash(string(legacy), testArgon2Params) {
+		t.Error("bcrypt hash should need a rehash")
+	}
+}
+
+func TestCheckPasswordHashRejectsBadHashes(t *testing.T) {
+	tests := []struct {
+		hash     string
+		expected error
+	}{
+		{"unset", ErrPasswordUnset},
+		{"", ErrPasswordUnset},
+		{"plaintext", ErrUnknownHashFormat},
+		{"$argon2id$v=19$m=1024,t=1,p=1$$", ErrUnknownHashFormat},
+		{"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHQ$a2V5a2V5", ErrUnknownHashFormat},
+		{"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5", ErrUnknownHashFormat},
+	}
+
+	for _, test := range tests {
+		err := CheckPasswordHash("anything", test.hash)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("CheckPasswordHash(%q) = %v, expected %v", test.hash, err, test.expected)
+		}
+	}
+}
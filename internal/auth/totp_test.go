@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestHOTPRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+
+	for _, test := range tests {
+		got := hotp(key, uint64(test.unix/30), 8)
+		if got != test.expected {
+			t.Errorf("hotp at %d = %s, expected %s", test.unix, got, test.expected)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
+	now := time.Unix(1234567890, 0)
+	code := hotp(key, uint64(now.Unix()/30), totpDigits)
+
+	counter, ok := ValidateTOTP(code, secret, now)
+	if !ok || counter != now.Unix()/30 {
+		t.Errorf("ValidateTOTP(%s) = %d, %v; expected %d, true", code, counter, ok, now.Unix()/30)
+	}
+
+	_, ok = ValidateTOTP(code, secret, now.Add(30*time.Second))
+	if !ok {
+		t.Error("ValidateTOTP should accept a code from the previous period")
+	}
+
+	_, ok = ValidateTOTP(code, secret, now.Add(2*time.Minute))
+	if ok {
+		t.Error("ValidateTOTP accepted a code two minutes old")
+	}
+
+	_, ok = ValidateTOTP("000000", secret, now)
+	if ok && code != "000000" {
+		t.Error("ValidateTOTP accepted the wrong code")
+	}
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	Issuer = "chirpy"
+	// AccessTokenAudience is the audience of access tokens. Other services
+	// verifying Chirpy tokens should require it.
+	AccessTokenAudience = "chirpy-api"
+	// MFA challenge tokens get their own audience so they can never be
+	// accepted as access tokens.
+	mfaTokenAudience = "chirpy-mfa"
+)
+
+var (
+	ErrTokenMalformed     = errors.New("token is malformed")
+	ErrTokenExpired       = errors.New("token has expired")
+	ErrTokenNotYetValid   = errors.New("token is not valid yet")
+	ErrTokenBadSignature  = errors.New("token signature is invalid")
+	ErrTokenWrongIssuer   = errors.New("token has the wrong issuer")
+	ErrTokenWrongAudience = errors.New("token has the wrong audience")
+	ErrTokenMissingClaim  = errors.New("token is missing a required claim")
+)
+
+// ValidationOptions describes what a token must look like to be accepted.
+// Empty fields skip that check.
+type ValidationOptions struct {
+	// Algorithms lists the accepted alg header values.
+	Algorithms []string
+	Issuer     string
+	// Audiences passes when the token names at least one of them.
+	Audiences []string
+	// Leeway allows for clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+	// RequiredClaims lists claims that must be present, by their JSON name.
+	RequiredClaims []string
+}
+
+var (
+	AccessTokenOptions = ValidationOptions{
+		Algorithms:     []string{"EdDSA", "RS256"},
+		Issuer:         Issuer,
+		Audiences:      []string{AccessTokenAudience},
+		RequiredClaims: []string{"exp", "iat", "sub"},
+	}
+	mfaTokenOptions = ValidationOptions{
+		Algorithms:     []string{"EdDSA", "RS256"},
+		Issuer:         Issuer,
+		Audiences:      []string{mfaTokenAudience},
+		RequiredClaims: []string{"exp", "iat", "sub"},
+	}
+)
+
+// ParseJWT verifies tokenString against keys and opts. Errors wrap one of
+// the ErrToken values so callers can tell why a token was refused.
+func ParseJWT(tokenString string, keys *KeyRing, opts ValidationOptions) (*Claims, error) {
+	parserOpts := []jwt.ParserOption{jwt.WithLeeway(opts.Leeway)}
+	if len(opts.Algorithms) > 0 {
+		parserOpts = append(parserOpts, jwt.WithValidMethods(opts.Algorithms))
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, keys.keyFunc, parserOpts...)
+	if err != nil {
+		return nil, classifyJWTError(err)
+	}
+
+	if len(opts.Audiences) > 0 && !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(opts.Audiences, aud)
+	}) {
+		return nil, ErrTokenWrongAudience
+	}
+
+	for _, name := range opts.RequiredClaims {
+		if !claims.has(name) {
+			return nil, fmt.Errorf("%w: %s", ErrTokenMissingClaim, name)
+		}
+	}
+
+	return claims, nil
+}
+
+func classifyJWTError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return fmt.Errorf("%w: %w", ErrTokenExpired, err)
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return fmt.Errorf("%w: %w", ErrTokenNotYetValid, err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return fmt.Errorf("%w: %w", ErrTokenBadSignature, err)
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return fmt.Errorf("%w: %w", ErrTokenWrongIssuer, err)
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return fmt.Errorf("%w: %w", ErrTokenWrongAudience, err)
+	}
+	return fmt.Errorf("%w: %w", ErrTokenMalformed, err)
+}
+
+func (claims *Claims) has(name string) bool {
+	switch name {
+	case "exp":
+		return claims.ExpiresAt != nil
+	case "iat":
+		return claims.IssuedAt != nil
+	case "nbf":
+		return claims.NotBefore != nil
+	case "iss":
+		return claims.Issuer != ""
+	case "sub":
+		return claims.Subject != ""
+	case "aud":
+		return len(claims.Audience) > 0
+	case "jti":
+		return claims.ID != ""
+	case "role":
+		return claims.Role != ""
+	}
+	return false
+}
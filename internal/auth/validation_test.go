@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestParseJWTErrors(t *testing.T) {
+	keys := testKeyRing(t, "test")
+	otherKeys := testKeyRing(t, "test")
+	userID := uuid.New()
+
+	valid, _ := MakeJWT(userID, RoleUser, keys, time.Minute)
+	expired, _ := MakeJWT(userID, RoleUser, keys, -time.Minute)
+	mfaToken, _ := MakeMFAToken(userID, keys, time.Minute)
+
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    Issuer,
+		Audience:  jwt.ClaimStrings{AccessTokenAudience},
+		Subject:   userID.String(),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	hmacToken.Header["kid"] = "test"
+	forged, _ := hmacToken.SignedString([]byte("guessed"))
+
+	noExpiry, _ := keys.Sign(jwt.RegisteredClaims{
+		Issuer:   Issuer,
+		Audience: jwt.ClaimStrings{AccessTokenAudience},
+		Subject:  userID.String(),
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+	})
+
+	tests := []struct {
+		name     string
+		token    string
+		keys     *KeyRing
+		expected error
+	}{
+		{"valid", valid, keys, nil},
+		{"expired", expired, keys, ErrTokenExpired},
+		{"other key", valid, otherKeys, ErrTokenBadSignature},
+		{"wrong algorithm", forged, keys, ErrTokenBadSignature},
+		{"mfa token", mfaToken, keys, ErrTokenWrongAudience},
+		{"no expiry", noExpiry, keys, ErrTokenMissingClaim},
+		{"garbage", "not.a.token", keys, ErrTokenMalformed},
+	}
+
+	for _, test := range tests {
+		_, err := ParseJWT(test.token, test.keys, AccessTokenOptions)
+		if test.expected == nil && err != nil {
+			t.Errorf("%s: ParseJWT returned an err: %v", test.name, err)
+		}
+		if test.expected != nil && !errors.Is(err, test.expected) {
+			t.Errorf("%s: ParseJWT returned %v, expected %v", test.name, err, test.expected)
+		}
+	}
+}
+
+func TestParseJWTLeeway(t *testing.T) {
+	keys := testKeyRing(t, "test")
+	recentlyExpired, _ := MakeJWT(uuid.New(), RoleUser, keys, -2*time.Second)
+
+	opts := AccessTokenOptions
+	opts.Leeway = 10 * time.Second
+	_, err := ParseJWT(recentlyExpired, keys, opts)
+	if err != nil {
+		t.Errorf("ParseJWT with leeway returned an err: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package chirptext
+
+import (
+	"regexp"
+
+	"github.com/rivo/uniseg"
+)
+
+// URLWeight is what every URL counts for, however long it is.
+const URLWeight = 23
+
+// MaxURLBytes is the longest link that is weighted as a URL. Anything
+// longer counts character by character like the rest of the body.
+const MaxURLBytes = 2048
+
+// MaxGraphemeBytes is the most a single character may take before it
+// counts again, so a body packed with combining marks can't stay "short"
+// while growing without end. It leaves room for the longest emoji
+// sequences.
+const MaxGraphemeBytes = 64
+
+// MaxBytesPerChar bounds how many bytes a body can take per unit of its
+// Length. The worst case is a URL of MaxURLBytes counted as URLWeight.
+const MaxBytesPerChar = (MaxURLBytes + URLWeight - 1) / URLWeight
+
+var urlRegexp = regexp.MustCompile(`(?i)\bhttps?://[^\s]+`)
+
+// Length counts what a reader sees: grapheme clusters, so emoji and
+// combining sequences count once, with every URL weighted as URLWeight.
+func Length(body string) int {
+	length := 0
+
+	start := 0
+	for _, url := range urlRegexp.FindAllStringIndex(body, -1) {
+		if url[1]-url[0] > MaxURLBytes {
+			continue
+		}
+		length += graphemeLength(body[start:url[0]]) + URLWeight
+		start = url[1]
+	}
+	length += graphemeLength(body[start:])
+
+	return length
+}
+
+// graphemeLength counts the grapheme clusters in text, counting an
+// oversized cluster once for every MaxGraphemeBytes it takes.
+func graphemeLength(text string) int {
+	length := 0
+	state := -1
+	for len(text) > 0 {
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		length += (len(cluster) + MaxGraphemeBytes - 1) / MaxGraphemeBytes
+	}
+	return length
+}
@@ -0,0 +1,60 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello world", 11},
+		{"url", "see https://example.com/a/very/long/path?with=query", 4 + URLWeight},
+		{"short url", "http://a.b", URLWeight},
+		{"two urls", "https://a.example https://b.example", 2*URLWeight + 1},
+		{"url case", "HTTPS://EXAMPLE.COM", URLWeight},
+		{"not a url", "ftp://example.com", 17},
+		{"zwj emoji", "\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466", 1},
+		{"flag", "\U0001F1FA\U0001F1F8", 1},
+		{"skin tone", "\U0001F44D\U0001F3FD", 1},
+		{"combining mark", "e\u0301", 1},
+		{"stacked marks", "a\u0300\u0301\u0302 b", 3},
+		{"precomposed", "caf\u00e9", 4},
+		{"cjk", "日本語", 3},
+		{"zalgo", "a" + strings.Repeat("\u0301", 40), 2},
+		{"oversized url", "https://example.com/" + strings.Repeat("x", MaxURLBytes), MaxURLBytes + 20},
+	}
+
+	for _, test := range tests {
+		if got := Length(test.body); got != test.expected {
+			t.Errorf("%s: Length(%q) = %d, expected %d", test.name, test.body, got, test.expected)
+		}
+	}
+}
+
+func TestLengthIgnoresURLSize(t *testing.T) {
+	short := Length("https://example.com")
+	long := Length("https://example.com/" + strings.Repeat("x", 1000))
+	if short != long {
+		t.Errorf("URL lengths differ: %d and %d", short, long)
+	}
+}
+
+func TestLengthBoundsBytes(t *testing.T) {
+	bodies := []string{
+		"https://example.com/" + strings.Repeat("x", MaxURLBytes-20),
+		strings.Repeat("\U0001F1FA\U0001F1F8", 100),
+		strings.Repeat("\U0001F468\u200D\U0001F469\u200D\U0001F467\u200D\U0001F466", 100),
+		"a" + strings.Repeat("\u0301", 1000),
+	}
+
+	for _, body := range bodies {
+		if length := Length(body); len(body) > MaxBytesPerChar*length {
+			t.Errorf("Length(%.20q...) = %d, but the body takes %d bytes", body, length, len(body))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	thumb := Thumbnail(src, 100)
+	if thumb.Bounds().Dx() != 100 || thumb.Bounds().Dy() != 50 {
+		t.Errorf("Thumbnail size = %v, expected 100x50", thumb.Bounds().Size())
+	}
+
+	r, g, b, a := thumb.At(10, 10).RGBA()
+	if r>>8 != 255 || g != 0 || b != 0 || a>>8 != 255 {
+		t.Errorf("Thumbnail pixel = (%d, %d, %d, %d), expected opaque red", r>>8, g>>8, b>>8, a>>8)
+	}
+
+	small := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	if Thumbnail(small, 100) != image.Image(small) {
+		t.Error("Thumbnail should return images that already fit unchanged")
+	}
+}
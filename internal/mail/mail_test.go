@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMessage(t *testing.T) {
+	date := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	data, err := formatMessage("Chirpy <no-reply@chirpy.test>", Message{
+		To:      "user@example.com",
+		Subject: "Réinitialiser",
+		Body:    "line one\nline two",
+	}, date)
+	if err != nil {
+		t.Fatalf("formatMessage: %s", err)
+	}
+
+	got := string(data)
+	for _, want := range []string{
+		"From: Chirpy <no-reply@chirpy.test>\r\n",
+		"To: user@example.com\r\n",
+		"Subject: =?utf-8?q?R=C3=A9initialiser?=\r\n",
+		"Date: Fri, 01 Mar 2024 09:30:00 +0000\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message is missing %q:\n%s", want, got)
+		}
+	}
+
+	_, err = formatMessage("a@example.com", Message{To: "b@example.com\r\nBcc: c@example.com"}, date)
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	outbox := NewOutbox(dir, "no-reply@chirpy.test")
+
+	msg := Message{To: "user@example.com", Subject: "Hello", Body: "Hi there"}
+	err := outbox.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Send: %s", err)
+	}
+
+	messages := outbox.Messages()
+	if len(messages) != 1 || messages[0] != msg {
+		t.Errorf("Messages() = %v, expected [%v]", messages, msg)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %s", err)
+	}
+	if len(files) != 1 || filepath.Ext(files[0].Name()) != ".eml" {
+		t.Errorf("expected one .eml file in the outbox, got %v", files)
+	}
+}
+
+func TestSMTPMailerTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+	defer listener.Close()
+
+	// Accept the connection but never greet, like a stuck relay.
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	mailer := NewSMTPMailer("127.0.0.1", addr.Port, "", "", "Chirpy <no-reply@chirpy.test>")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = mailer.Send(ctx, Message{To: "user@example.com", Subject: "hi", Body: "hi"})
+	if err == nil {
+		t.Fatal("expected an error from a relay that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send took %s, expected it to give up at the deadline", elapsed)
+	}
+}
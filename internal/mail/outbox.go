@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outbox is a Mailer that keeps messages instead of delivering them. Tests
+// read them back with Messages; when dir is set each message is also written
+// there as an .eml file, which is handy in local development.
+type Outbox struct {
+	mu       sync.Mutex
+	dir      string
+	from     string
+	messages []Message
+}
+
+func NewOutbox(dir, from string) *Outbox {
+	return &Outbox{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	data, err := formatMessage(o.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.dir != "" {
+		err = os.MkdirAll(o.dir, 0o750)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s-%04d.eml", now.Format("20060102T150405"), len(o.messages)+1)
+		err = os.WriteFile(filepath.Join(o.dir, name), data, 0o640)
+		if err != nil {
+			return err
+		}
+	}
+
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]Message(nil), o.messages...)
+}
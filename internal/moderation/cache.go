@@ -0,0 +1,53 @@
+package moderation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache holds the compiled Filter in memory. It reloads the rules when
+// Invalidate has been called or when the cached copy is older than ttl, so
+// changes made by other instances are picked up too.
+type Cache struct {
+	mu       sync.RWMutex
+	load     func(ctx context.Context) ([]Rule, error)
+	ttl      time.Duration
+	filter   *Filter
+	loadedAt time.Time
+}
+
+func NewCache(load func(ctx context.Context) ([]Rule, error), ttl time.Duration) *Cache {
+	return &Cache{
+		load: load,
+		ttl:  ttl,
+	}
+}
+
+func (c *Cache) Filter(ctx context.Context) (*Filter, error) {
+	c.mu.RLock()
+	filter := c.filter
+	fresh := filter != nil && time.Since(c.loadedAt) < c.ttl
+	c.mu.RUnlock()
+	if fresh {
+		return filter, nil
+	}
+
+	return c.Refresh(ctx)
+}
+
+func (c *Cache) Refresh(ctx context.Context) (*Filter, error) {
+	rules, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := NewFilter(rules)
+
+	c.mu.Lock()
+	c.filter = filter
+	c.loadedAt = time.Now()
+	c.mu.Unlock()
+
+	return filter, nil
+}
@@ -0,0 +1,315 @@
+package moderation
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type MatchMode string
+
+// Match modes are cumulative: each one applies every normalization of the
+// modes before it.
+const (
+	// MatchWholeWord matches a whitespace separated word, ignoring case.
+	MatchWholeWord MatchMode = "whole_word"
+	// MatchPunctuation also treats punctuation as a word boundary, so
+	// "Kerfuffle!" and "kerfuffle,sharbert" match, and skips it inside a
+	// word, so "ker-fuffle" does too.
+	MatchPunctuation MatchMode = "punctuation"
+	// MatchUnicode also folds compatibility forms and strips accents, so
+	// "kérfüffle" and full width letters match.
+	MatchUnicode MatchMode = "unicode"
+	// MatchLeetspeak also reads common digit and symbol substitutions as
+	// letters, so "k3rfuff1e" matches. Text is matched both with and without
+	// those substitutions, so "Kerfuffle!" still matches as well.
+	MatchLeetspeak MatchMode = "leetspeak"
+)
+
+type Action string
+
+const (
+	ActionMask   Action = "mask"
+	ActionReject Action = "reject"
+	ActionFlag   Action = "flag"
+)
+
+const mask = "****"
+
+// leetFolds maps each substitution to the letters it can stand for. Letters
+// never fold to other letters, so "heil" can't match "hell".
+var leetFolds = map[rune]string{
+	'0': "o",
+	'1': "il",
+	'!': "i",
+	'|': "il",
+	'3': "e",
+	'4': "a",
+	'@': "a",
+	'5': "s",
+	'$': "s",
+	'7': "t",
+	'+': "t",
+	'8': "b",
+	'9': "g",
+}
+
+func ValidMatchMode(mode MatchMode) bool {
+	switch mode {
+	case MatchWholeWord, MatchPunctuation, MatchUnicode, MatchLeetspeak:
+		return true
+	}
+	return false
+}
+
+func ValidAction(action Action) bool {
+	switch action {
+	case ActionMask, ActionReject, ActionFlag:
+		return true
+	}
+	return false
+}
+
+type Rule struct {
+	Word   string
+	Mode   MatchMode
+	Action Action
+}
+
+type Result struct {
+	// Body is the input with every matched word masked.
+	Body     string
+	Rejected bool
+	Flagged  bool
+	Matches  []Rule
+}
+
+type compiledRule struct {
+	Rule
+	key []rune
+}
+
+type Filter struct {
+	rules []compiledRule
+}
+
+func NewFilter(rules []Rule) *Filter {
+	f := &Filter{}
+	for _, rule := range rules {
+		key := normalize(rule.Word, keyMode(rule.Mode)).runes
+		if len(key) == 0 {
+			continue
+		}
+		f.rules = append(f.rules, compiledRule{
+			Rule: rule,
+			key:  key,
+		})
+	}
+
+	return f
+}
+
+// Check matches every rule against body. A match must start and end on a
+// word boundary, and only the matched span is masked, so the punctuation
+// around it is kept.
+func (f *Filter) Check(body string) Result {
+	result := Result{}
+	seen := map[string]struct{}{}
+	spans := []span{}
+
+	normalized := map[MatchMode]normalizedText{}
+	for _, rule := range f.rules {
+		matches := []span{}
+		for _, mode := range textModes(rule.Mode) {
+			text, ok := normalized[mode]
+			if !ok {
+				text = normalize(body, mode)
+				normalized[mode] = text
+			}
+			matches = append(matches, text.find(rule.key, mode)...)
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		seenKey := string(rule.key) + "|" + string(rule.Action)
+		if _, ok := seen[seenKey]; !ok {
+			seen[seenKey] = struct{}{}
+			result.Matches = append(result.Matches, rule.Rule)
+		}
+		switch rule.Action {
+		case ActionMask:
+			spans = append(spans, matches...)
+		case ActionReject:
+			result.Rejected = true
+		case ActionFlag:
+			result.Flagged = true
+		}
+	}
+
+	result.Body = maskSpans(body, spans)
+	return result
+}
+
+// keyMode is the mode a rule's word is normalized in. Leetspeak rules are
+// plain words, so their substitutions are only read on the text side.
+func keyMode(mode MatchMode) MatchMode {
+	if mode == MatchLeetspeak {
+		return MatchUnicode
+	}
+	return mode
+}
+
+// textModes lists the forms of the text a rule is matched against.
+// Leetspeak symbols like '!' also work as punctuation, so leetspeak rules
+// match the punctuation separated form too.
+func textModes(mode MatchMode) []MatchMode {
+	if mode == MatchLeetspeak {
+		return []MatchMode{MatchLeetspeak, MatchUnicode}
+	}
+	return []MatchMode{mode}
+}
+
+// span is a byte range of the original text.
+type span struct {
+	start, end int
+}
+
+// normalizedText is text folded for one match mode, remembering where each
+// folded rune came from and whether a word boundary comes before it.
+type normalizedText struct {
+	runes    []rune
+	spans    []span
+	boundary []bool
+}
+
+func normalize(text string, mode MatchMode) normalizedText {
+	result := normalizedText{}
+	atBoundary := true
+
+	for i, r := range text {
+		if isSeparator(r, mode) {
+			atBoundary = true
+			continue
+		}
+
+		for _, f := range foldRune(r, mode) {
+			result.runes = append(result.runes, f)
+			result.spans = append(result.spans, span{start: i, end: i + utf8.RuneLen(r)})
+			result.boundary = append(result.boundary, atBoundary)
+			atBoundary = false
+		}
+	}
+
+	return result
+}
+
+// find returns the original spans where key occurs between two word
+// boundaries.
+func (t normalizedText) find(key []rune, mode MatchMode) []span {
+	matches := []span{}
+	for start := 0; start+len(key) <= len(t.runes); start++ {
+		end := start + len(key)
+		if !t.boundary[start] || (end < len(t.runes) && !t.boundary[end]) {
+			continue
+		}
+		if slices.EqualFunc(t.runes[start:end], key, func(r, k rune) bool {
+			return runeMatches(r, k, mode)
+		}) {
+			matches = append(matches, span{start: t.spans[start].start, end: t.spans[end-1].end})
+		}
+	}
+	return matches
+}
+
+// runeMatches reports whether the text rune r can stand for the key rune k.
+func runeMatches(r, k rune, mode MatchMode) bool {
+	if r == k {
+		return true
+	}
+	if mode != MatchLeetspeak {
+		return false
+	}
+	return strings.ContainsRune(leetFolds[r], k)
+}
+
+// isSeparator reports whether r splits words in the given mode. Whole word
+// matching splits on whitespace only; the other modes split on anything
+// that isn't part of a word, except the symbols leetspeak uses as letters.
+func isSeparator(r rune, mode MatchMode) bool {
+	if unicode.IsSpace(r) {
+		return true
+	}
+	if mode == MatchWholeWord {
+		return false
+	}
+	if _, ok := leetFolds[r]; ok && mode == MatchLeetspeak {
+		return false
+	}
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.M, r)
+}
+
+// foldRune returns what r compares as in the given mode, which may be
+// nothing, as for combining marks, or several runes, as for ligatures.
+// Leetspeak symbols are kept as they are and read as letters by
+// runeMatches.
+func foldRune(r rune, mode MatchMode) string {
+	folded := strings.ToLower(string(r))
+	if mode == MatchWholeWord {
+		return folded
+	}
+
+	if mode == MatchUnicode || mode == MatchLeetspeak {
+		folded = stripMarks(folded)
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		if _, ok := leetFolds[r]; ok && mode == MatchLeetspeak {
+			return r
+		}
+		return -1
+	}, folded)
+}
+
+// maskSpans replaces each run of overlapping spans in text with the mask.
+func maskSpans(text string, spans []span) string {
+	if len(spans) == 0 {
+		return text
+	}
+
+	slices.SortFunc(spans, func(a, b span) int {
+		return a.start - b.start
+	})
+
+	out := strings.Builder{}
+	written := 0
+	for i := 0; i < len(spans); {
+		start, end := spans[i].start, spans[i].end
+		for i++; i < len(spans) && spans[i].start < end; i++ {
+			end = max(end, spans[i].end)
+		}
+		out.WriteString(text[written:start])
+		out.WriteString(mask)
+		written = end
+	}
+	out.WriteString(text[written:])
+
+	return out.String()
+}
+
+// stripMarks applies NFKD so compatibility characters and accented letters
+// decompose, then drops the combining marks.
+func stripMarks(word string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFKD.String(word)))
+}
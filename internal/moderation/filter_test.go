@@ -0,0 +1,127 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFilterMatchModes(t *testing.T) {
+	tests := []struct {
+		mode    MatchMode
+		body    string
+		matched bool
+	}{
+		{MatchWholeWord, "what a Kerfuffle", true},
+		{MatchWholeWord, "what a Kerfuffle!", false},
+		{MatchPunctuation, "what a Kerfuffle!", true},
+		{MatchPunctuation, "kerfuffle,sharbert", true},
+		{MatchPunctuation, "a ker-fuffle", true},
+		{MatchPunctuation, "(kerfuffle)", true},
+		{MatchPunctuation, "kerfuffles", false},
+		{MatchPunctuation, "unkerfuffle", false},
+		{MatchPunctuation, "what a kérfüffle", false},
+		{MatchUnicode, "what a kérfüffle", true},
+		{MatchUnicode, "what a ｋｅｒｆｕｆｆｌｅ", true},
+		{MatchUnicode, "what a k3rfuff1e", false},
+		{MatchLeetspeak, "what a k3rfuff1e", true},
+		{MatchLeetspeak, "what a K3RFÜFF|3.", true},
+		{MatchLeetspeak, "what a kerfuffles", false},
+		{MatchLeetspeak, "what a Kerfuffle!", true},
+		{MatchLeetspeak, "kerfuffle,sharbert", true},
+		{MatchLeetspeak, "a ker-fuffle", true},
+	}
+
+	for _, test := range tests {
+		filter := NewFilter([]Rule{{Word: "kerfuffle", Mode: test.mode, Action: ActionMask}})
+		result := filter.Check(test.body)
+		if (len(result.Matches) > 0) != test.matched {
+			t.Errorf("Check(%q) with mode %s matched = %v, expected %v", test.body, test.mode, !test.matched, test.matched)
+		}
+	}
+}
+
+func TestFilterActions(t *testing.T) {
+	filter := NewFilter([]Rule{
+		{Word: "kerfuffle", Mode: MatchPunctuation, Action: ActionMask},
+		{Word: "sharbert", Mode: MatchWholeWord, Action: ActionReject},
+		{Word: "fornax", Mode: MatchWholeWord, Action: ActionFlag},
+	})
+
+	result := filter.Check("I had a Kerfuffle!  today")
+	if result.Body != "I had a ****!  today" {
+		t.Errorf("Check masked body = %q", result.Body)
+	}
+	if result.Rejected || result.Flagged {
+		t.Errorf("Check should only mask, got rejected=%v flagged=%v", result.Rejected, result.Flagged)
+	}
+
+	if !filter.Check("sharbert").Rejected {
+		t.Error("Check expected sharbert to be rejected")
+	}
+
+	result = filter.Check("\"kerfuffle,kerfuffle\" and ker-fuffle.")
+	if result.Body != "\"****,****\" and ****." {
+		t.Errorf("Check masked body = %q", result.Body)
+	}
+
+	result = filter.Check("fornax")
+	if !result.Flagged || result.Body != "fornax" {
+		t.Errorf("Check expected fornax to be flagged and left unmasked, got %+v", result)
+	}
+}
+
+func TestFilterSplitsOnPunctuation(t *testing.T) {
+	filter := NewFilter([]Rule{
+		{Word: "kerfuffle", Mode: MatchPunctuation, Action: ActionMask},
+		{Word: "sharbert", Mode: MatchPunctuation, Action: ActionReject},
+	})
+
+	result := filter.Check("kerfuffle,sharbert")
+	if !result.Rejected || len(result.Matches) != 2 {
+		t.Errorf("Check expected both words to match, got %+v", result)
+	}
+	if result.Body != "****,sharbert" {
+		t.Errorf("Check masked body = %q", result.Body)
+	}
+}
+
+func TestFilterLeetspeakDoesNotFoldLetters(t *testing.T) {
+	filter := NewFilter([]Rule{{Word: "hell", Mode: MatchLeetspeak, Action: ActionMask}})
+
+	result := filter.Check("heil there")
+	if len(result.Matches) != 0 || result.Body != "heil there" {
+		t.Errorf("Check expected heil to be left alone, got %+v", result)
+	}
+
+	result = filter.Check("he11 no, Hell!")
+	if result.Body != "**** no, ****!" {
+		t.Errorf("Check masked body = %q", result.Body)
+	}
+}
+
+func TestCacheRefresh(t *testing.T) {
+	loads := 0
+	cache := NewCache(func(ctx context.Context) ([]Rule, error) {
+		loads++
+		return []Rule{{Word: "kerfuffle", Mode: MatchWholeWord, Action: ActionMask}}, nil
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.Filter(context.Background())
+		if err != nil {
+			t.Fatalf("Filter returned an err: %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("Filter loaded rules %d times, expected 1", loads)
+	}
+
+	_, err := cache.Refresh(context.Background())
+	if err != nil {
+		t.Fatalf("Refresh returned an err: %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("Refresh should reload rules, loads = %d", loads)
+	}
+}
@@ -0,0 +1,69 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLen is the length of the SHA-1 hex prefix a range is keyed by, as
+// in the Have I Been Pwned k-anonymity API.
+const prefixLen = 5
+
+// BreachedList is a local mirror of the Have I Been Pwned password ranges,
+// laid out the way the HIBP downloader writes them: one <PREFIX>.txt file
+// per five hex digit SHA-1 prefix, each line a SUFFIX:COUNT pair. A lookup
+// only ever reads the one range file its hash falls in, so the full dump
+// never has to fit in memory.
+type BreachedList struct {
+	dir string
+}
+
+// OpenBreachedList returns a BreachedList reading range files from dir.
+func OpenBreachedList(dir string) (*BreachedList, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	return &BreachedList{dir: dir}, nil
+}
+
+// Contains reports whether password's SHA-1 hash appears in its range. A
+// missing range file means no password with that prefix was breached.
+func (l *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+
+	file, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading range %s: %w", prefix, err)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,99 @@
+package passwords
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// maxLength bounds the work the strength estimator and the hasher do for a
+// single request.
+const maxLength = 128
+
+const (
+	CodeTooShort    = "too_short"
+	CodeTooLong     = "too_long"
+	CodeTooWeak     = "too_weak"
+	CodeMatchesUser = "matches_email"
+	CodeBreached    = "breached"
+)
+
+// Violation is one way a password fails the policy.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Policy is the set of rules new passwords must pass. MinScore is a zxcvbn
+// score from 0 (guessable in seconds) to 4 (very strong). Breached may be nil
+// to skip the breach check.
+type Policy struct {
+	MinLength int
+	MinScore  int
+	Breached  *BreachedList
+}
+
+// Check returns every rule password breaks, or nil if it is acceptable.
+// email is rejected as a password and also counts against its strength. An
+// error means the breach check itself couldn't be done.
+func (p Policy) Check(password, email string) ([]Violation, error) {
+	violations := []Violation{}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{
+			Code:    CodeTooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters", p.MinLength),
+		})
+	}
+	if length > maxLength {
+		// Everything below is too slow on huge inputs, so stop here.
+		return append(violations, Violation{
+			Code:    CodeTooLong,
+			Message: fmt.Sprintf("Password must be at most %d characters", maxLength),
+		}), nil
+	}
+
+	userInputs := []string{}
+	if email != "" {
+		localPart, _, _ := strings.Cut(email, "@")
+		userInputs = append(userInputs, email, localPart)
+
+		for _, input := range userInputs {
+			if strings.EqualFold(password, input) {
+				violations = append(violations, Violation{
+					Code:    CodeMatchesUser,
+					Message: "Password can't be your email address",
+				})
+				break
+			}
+		}
+	}
+
+	if password != "" && zxcvbn.PasswordStrength(password, userInputs).Score < p.MinScore {
+		violations = append(violations, Violation{
+			Code:    CodeTooWeak,
+			Message: "Password is too easy to guess",
+		})
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			violations = append(violations, Violation{
+				Code:    CodeBreached,
+				Message: "Password has appeared in a data breach",
+			})
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil, nil
+	}
+	return violations, nil
+}
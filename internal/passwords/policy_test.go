@@ -0,0 +1,100 @@
+package passwords
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// SHA-1 of "password".
+const passwordSHA1 = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func testPolicy(t *testing.T) Policy {
+	t.Helper()
+
+	dir := t.TempDir()
+	ranges := map[string]string{
+		passwordSHA1[:prefixLen] + ".txt": "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" +
+			passwordSHA1[prefixLen:] + ":9545824\r\n",
+		"7C4A8.txt": "d09ca3762af61e59520943dc26494f8941b:37359195\n",
+	}
+	for name, contents := range ranges {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644)
+		if err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+	}
+
+	breached, err := OpenBreachedList(dir)
+	if err != nil {
+		t.Fatalf("OpenBreachedList: %s", err)
+	}
+
+	return Policy{MinLength: 8, MinScore: 3, Breached: breached}
+}
+
+func codes(violations []Violation) []string {
+	result := []string{}
+	for _, v := range violations {
+		result = append(result, v.Code)
+	}
+	return result
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := testPolicy(t)
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		expected []string
+	}{
+		{"empty", "", "a@example.com", []string{CodeTooShort}},
+		{"strong", "correct horse battery staple", "a@example.com", []string{}},
+		{"breached", "password", "a@example.com", []string{CodeTooWeak, CodeBreached}},
+		{"email", "Walter.White@example.com", "walter.white@example.com", []string{CodeMatchesUser, CodeTooWeak}},
+		{"local part", "walter.white", "walter.white@example.com", []string{CodeMatchesUser, CodeTooWeak}},
+		{"too long", strings.Repeat("x", maxLength+1), "", []string{CodeTooLong}},
+	}
+
+	for _, test := range tests {
+		violations, err := policy.Check(test.password, test.email)
+		if err != nil {
+			t.Fatalf("%s: Check: %s", test.name, err)
+		}
+		got := codes(violations)
+		if strings.Join(got, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: got violations %v, expected %v", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestBreachedList(t *testing.T) {
+	list := testPolicy(t).Breached
+
+	tests := []struct {
+		password string
+		expected bool
+	}{
+		{"password", true},
+		{"123456", true},
+		{"not in the list", false},
+	}
+
+	for _, test := range tests {
+		got, err := list.Contains(test.password)
+		if err != nil {
+			t.Fatalf("Contains(%q): %s", test.password, err)
+		}
+		if got != test.expected {
+			t.Errorf("Contains(%q) = %v, expected %v", test.password, got, test.expected)
+		}
+	}
+
+	_, err := OpenBreachedList(filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFSBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFSBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBlobStore returned an err: %v", err)
+	}
+
+	data := []byte("not really a png")
+	err = store.Put(ctx, "media/test.png", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Put returned an err: %v", err)
+	}
+
+	rc, err := store.Open(ctx, "media/test.png")
+	if err != nil {
+		t.Fatalf("Open returned an err: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("Open returned %q, expected %q", got, data)
+	}
+
+	err = store.Delete(ctx, "media/test.png")
+	if err != nil {
+		t.Errorf("Delete returned an err: %v", err)
+	}
+
+	_, err = store.Open(ctx, "media/test.png")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete returned %v, expected ErrNotFound", err)
+	}
+}
+
+func TestFSBlobStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewFSBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSBlobStore returned an err: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "media/../../outside", "media//x"} {
+		err := store.Put(context.Background(), key, bytes.NewReader(nil))
+		if err == nil {
+			t.Errorf("Put(%q) expected an error for an invalid key", key)
+		}
+	}
+}
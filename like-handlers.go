@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleLikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, true)
+}
+
+func (cfg *apiConfig) handleUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, false)
+}
+
+// setChirpLike adds or removes the caller's like and responds with the
+// updated chirp, which for a rechirp is the original. The like row and the chirp's like_count change in the same
+// statement, so concurrent likes can't drift the counter.
+func (cfg *apiConfig) setChirpLike(w http.ResponseWriter, r *http.Request, like bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err == nil && chirp.RechirpOf.Valid {
+		// A rechirp has no body of its own, so the like goes to the
+		// original.
+		chirp, err = cfg.db.GetChirp(r.Context(), chirp.RechirpOf.UUID)
+	}
+	if err != nil || chirp.DeletedAt.Valid || chirp.HiddenAt.Valid {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	if like {
+		err = cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{
+			ChirpID: chirp.ID,
+			UserID:  userID,
+		})
+	} else {
+		err = cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+			ChirpID: chirp.ID,
+			UserID:  userID,
+		})
+	}
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't update like", err)
+		return
+	}
+
+	chirp, err = cfg.db.GetChirp(r.Context(), chirp.ID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(chirp)}
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, chirps[0])
+}
@@ -1,26 +1,59 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
 	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/mail"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/moderation"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/passwords"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileServerHits atomic.Int32
+	dbConn         *sql.DB
 	db             *database.Queries
 	platform       string
-	seceret        string
+	jwtKeys        *auth.KeyRing
 	polkaKey       string
+	blobs          storage.BlobStore
+	wordFilter     *moderation.Cache
+	chirpLimits    chirpLimits
+	passwordParams auth.Argon2Params
+	passwordPolicy passwords.Policy
+	mailer         mail.Mailer
+	adminEmail     string
 }
 
-const maxChirpLen = 140
+const (
+	defaultChirpLen    = 140
+	defaultRedChirpLen = 280
+)
+
+const (
+	defaultSMTPPort = 587
+	defaultMailFrom = "Chirpy <no-reply@localhost>"
+)
+
+const (
+	defaultPasswordMinLength = 8
+	defaultPasswordMinScore  = 2
+)
 
 func main() {
 	port := "8080"
@@ -35,15 +68,31 @@ func main() {
 	if platform == "" {
 		log.Fatal("env variable PLATFORM not set")
 	}
-	seceret := os.Getenv("SECERET")
-	if seceret == "" {
-		log.Fatal("env variable SECERET not set")
-	}
 	polkaKey := os.Getenv("POLKA_KEY")
 	if polkaKey == "" {
 		log.Fatal("env variable POLKA_KEY not set")
 	}
 
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+
+	chirpLimits := chirpLimits{
+		Standard: envPositiveInt("CHIRP_MAX_LEN", defaultChirpLen),
+		Red:      envPositiveInt("CHIRP_MAX_LEN_RED", defaultRedChirpLen),
+	}
+
+	jwtKeys, err := loadJWTKeys(platform)
+	if err != nil {
+		log.Fatalf("error loading JWT signing keys: %s", err)
+	}
+
+	mailer, err := loadMailer(platform)
+	if err != nil {
+		log.Fatalf("error setting up mail: %s", err)
+	}
+
 	dbCon, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("error connecting to db: %s", err)
@@ -52,29 +101,97 @@ func main() {
 
 	dbQueries := database.New(dbCon)
 
+	blobs, err := storage.NewFSBlobStore(mediaDir)
+	if err != nil {
+		log.Fatalf("error opening media storage: %s", err)
+	}
+
 	apiCfg := apiConfig{
 		fileServerHits: atomic.Int32{},
+		dbConn:         dbCon,
 		db:             dbQueries,
 		platform:       platform,
-		seceret:        seceret,
+		jwtKeys:        jwtKeys,
 		polkaKey:       polkaKey,
+		blobs:          blobs,
+		chirpLimits:    chirpLimits,
+		passwordParams: loadPasswordParams(),
+		passwordPolicy: loadPasswordPolicy(),
+		mailer:         mailer,
+		adminEmail:     strings.ToLower(strings.TrimSpace(os.Getenv("ADMIN_EMAIL"))),
+	}
+	apiCfg.wordFilter = moderation.NewCache(apiCfg.loadModerationRules, moderationCacheTTL)
+
+	if apiCfg.adminEmail != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		apiCfg.grantBootstrapAdmin(ctx, apiCfg.adminEmail)
+		cancel()
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetricsInc(http.FileServer(http.Dir(root)))))
 
 	mux.HandleFunc("GET /api/healthz", handleReadCheck)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handleJWKS)
+	mux.HandleFunc("GET /admin/metrics", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerMetrics))
+	mux.HandleFunc("POST /admin/reset", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handlerReset))
+	mux.HandleFunc("PUT /admin/users/{userID}/role", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handleSetUserRole))
+	mux.HandleFunc("POST /admin/users/{userID}/unlock", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handleUnlockUser))
+	mux.HandleFunc("GET /admin/moderation/words", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handleGetModerationWords))
+	mux.HandleFunc("POST /admin/moderation/words", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handleCreateModerationWord))
+	mux.HandleFunc("PUT /admin/moderation/words/{wordID}", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handleUpdateModerationWord))
+	mux.HandleFunc("DELETE /admin/moderation/words/{wordID}", apiCfg.middlewareRequireRole(auth.RoleAdmin, apiCfg.handleDeleteModerationWord))
+	mux.HandleFunc("GET /admin/moderation/flags", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handleGetChirpFlags))
+	mux.HandleFunc("GET /admin/moderation/actions", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handleGetModerationActions))
+	mux.HandleFunc("GET /admin/reports", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handleGetReports))
+	mux.HandleFunc("GET /admin/reports/{reportID}", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handleGetReport))
+	mux.HandleFunc("POST /admin/reports/{reportID}/triage", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handleTriageReport))
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", apiCfg.middlewareRequireRole(auth.RoleModerator, apiCfg.handleResolveReport))
 	mux.HandleFunc("POST /api/chirps", apiCfg.handleChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handleGetChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.handleSearchChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handleGetChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.handleEditChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handleDeleteChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.handleGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handleGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.handleLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.handleUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.handleRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.handleUndoRechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/reports", apiCfg.handleReportChirp)
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.handleGetTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.handleGetTagChirps)
+	mux.HandleFunc("POST /api/media", apiCfg.handleUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.handleGetMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnail", apiCfg.handleGetMediaThumbnail)
 	mux.HandleFunc("POST /api/users", apiCfg.handleCreateUser)
 	mux.HandleFunc("PUT /api/users", apiCfg.handleUserUpdate)
+	mux.HandleFunc("POST /api/email/verify", apiCfg.handleVerifyEmail)
+	mux.HandleFunc("POST /api/email/verify/resend", apiCfg.handleResendEmailVerification)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.handleFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.handleUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handleGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handleGetFollowing)
+	mux.HandleFunc("GET /api/timeline", apiCfg.handleGetTimeline)
+	mux.HandleFunc("GET /api/notifications", apiCfg.handleGetNotifications)
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.handleReadAllNotifications)
+	mux.HandleFunc("POST /api/notifications/{notificationID}/read", apiCfg.handleReadNotification)
 	mux.HandleFunc("POST /api/login", apiCfg.handleLogin)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.handleLoginMFA)
+	mux.HandleFunc("POST /api/mfa/totp", apiCfg.handleEnrollTOTP)
+	mux.HandleFunc("POST /api/mfa/totp/confirm", apiCfg.handleConfirmTOTP)
+	mux.HandleFunc("DELETE /api/mfa/totp", apiCfg.handleDisableTOTP)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handleRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handleRevoke)
+	mux.HandleFunc("POST /api/password/forgot", apiCfg.handleForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", apiCfg.handleResetPassword)
+	mux.HandleFunc("POST /api/logout-all", apiCfg.handleLogoutAll)
+	mux.HandleFunc("GET /api/sessions", apiCfg.handleGetSessions)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", apiCfg.handleDeleteSession)
+	mux.HandleFunc("POST /api/tokens", apiCfg.handleCreateAPIToken)
+	mux.HandleFunc("GET /api/tokens", apiCfg.handleGetAPITokens)
+	mux.HandleFunc("DELETE /api/tokens/{tokenID}", apiCfg.handleDeleteAPIToken)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handleUserUpgrade)
 
 	server := &http.Server{
@@ -86,3 +203,119 @@ func main() {
 	log.Fatal(server.ListenAndServe())
 
 }
+
+func envPositiveInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Fatalf("env variable %s must be a positive integer", name)
+	}
+
+	return n
+}
+
+// loadPasswordParams reads the argon2id cost from ARGON2_MEMORY_KIB,
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM. Raising them takes effect for
+// existing users the next time they log in.
+func loadPasswordParams() auth.Argon2Params {
+	params := auth.DefaultArgon2Params
+	params.Memory = uint32(envPositiveInt("ARGON2_MEMORY_KIB", int(params.Memory)))
+	params.Iterations = uint32(envPositiveInt("ARGON2_ITERATIONS", int(params.Iterations)))
+
+	parallelism := envPositiveInt("ARGON2_PARALLELISM", int(params.Parallelism))
+	if parallelism > math.MaxUint8 {
+		log.Fatalf("env variable ARGON2_PARALLELISM must be at most %d", math.MaxUint8)
+	}
+	params.Parallelism = uint8(parallelism)
+
+	return params
+}
+
+// loadPasswordPolicy reads PASSWORD_MIN_LENGTH, PASSWORD_MIN_SCORE (a zxcvbn
+// score from 1 to 4) and the optional BREACHED_PASSWORDS_DIR, a directory of
+// HIBP range files.
+func loadPasswordPolicy() passwords.Policy {
+	policy := passwords.Policy{
+		MinLength: envPositiveInt("PASSWORD_MIN_LENGTH", defaultPasswordMinLength),
+		MinScore:  envPositiveInt("PASSWORD_MIN_SCORE", defaultPasswordMinScore),
+	}
+	if policy.MinScore > 4 {
+		log.Fatal("env variable PASSWORD_MIN_SCORE must be at most 4")
+	}
+
+	breachedDir := os.Getenv("BREACHED_PASSWORDS_DIR")
+	if breachedDir == "" {
+		log.Print("BREACHED_PASSWORDS_DIR not set, skipping the breached password check")
+		return policy
+	}
+
+	breached, err := passwords.OpenBreachedList(breachedDir)
+	if err != nil {
+		log.Fatalf("error opening breached passwords: %s", err)
+	}
+	log.Printf("checking passwords against breached ranges in %s", breachedDir)
+	policy.Breached = breached
+
+	return policy
+}
+
+// loadMailer sends mail through SMTP_HOST. On the dev platform a missing
+// host falls back to writing mail to MAIL_OUTBOX_DIR as .eml files instead
+// of delivering it.
+func loadMailer(platform string) (mail.Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = defaultMailFrom
+	}
+
+	smtpHost := os.Getenv("SMTP_HOST")
+	if smtpHost != "" {
+		return mail.NewSMTPMailer(
+			smtpHost,
+			envPositiveInt("SMTP_PORT", defaultSMTPPort),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			from,
+		), nil
+	}
+
+	if platform != "dev" {
+		return nil, errors.New("env variable SMTP_HOST not set")
+	}
+
+	outboxDir := os.Getenv("MAIL_OUTBOX_DIR")
+	if outboxDir == "" {
+		outboxDir = "outbox"
+	}
+	log.Printf("SMTP_HOST not set, writing mail to %s", outboxDir)
+	return mail.NewOutbox(outboxDir, from), nil
+}
+
+// loadJWTKeys loads the signing keys from JWT_KEYS_DIR, signing with the key
+// named by JWT_ACTIVE_KID. On the dev platform a missing directory falls
+// back to a throwaway key, so tokens stop working on restart.
+func loadJWTKeys(platform string) (*auth.KeyRing, error) {
+	keysDir := os.Getenv("JWT_KEYS_DIR")
+	if keysDir != "" {
+		activeKID := os.Getenv("JWT_ACTIVE_KID")
+		if activeKID == "" {
+			return nil, errors.New("env variable JWT_ACTIVE_KID not set")
+		}
+		return auth.LoadKeyRing(keysDir, activeKID)
+	}
+
+	if platform != "dev" {
+		return nil, errors.New("env variable JWT_KEYS_DIR not set")
+	}
+
+	log.Printf("JWT_KEYS_DIR not set, signing tokens with a temporary key")
+	key, err := auth.GenerateEd25519Key("dev-" + uuid.NewString())
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewKeyRing(key.ID, key)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/imaging"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/storage"
+)
+
+func (cfg *apiConfig) handleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaSize+(1<<20))
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			responseError(w, http.StatusRequestEntityTooLarge, "Media too large", err)
+			return
+		}
+		responseError(w, http.StatusBadRequest, "Expected a multipart file field named file", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaSize+1))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Error reading upload", err)
+		return
+	}
+	if len(data) > maxMediaSize {
+		responseError(w, http.StatusRequestEntityTooLarge, "Media too large", nil)
+		return
+	}
+
+	// Trust the bytes, not the client supplied Content-Type.
+	contentType := http.DetectContentType(data)
+	if _, ok := allowedMediaTypes[contentType]; !ok {
+		responseError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported media type %s", contentType), nil)
+		return
+	}
+
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Couldn't read image", err)
+		return
+	}
+	if imgConfig.Width <= 0 || imgConfig.Height <= 0 || int64(imgConfig.Width)*int64(imgConfig.Height) > maxMediaPixels {
+		responseError(w, http.StatusBadRequest, "Image dimensions too large", nil)
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Couldn't decode image", err)
+		return
+	}
+
+	thumbnail := bytes.Buffer{}
+	err = png.Encode(&thumbnail, imaging.Thumbnail(img, thumbnailSize))
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating thumbnail", err)
+		return
+	}
+
+	mediaID := uuid.New()
+	storageKey := fmt.Sprintf("media/%s/original", mediaID)
+	thumbnailKey := fmt.Sprintf("media/%s/thumbnail", mediaID)
+
+	err = cfg.blobs.Put(r.Context(), storageKey, bytes.NewReader(data))
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error storing media", err)
+		return
+	}
+
+	err = cfg.blobs.Put(r.Context(), thumbnailKey, &thumbnail)
+	if err != nil {
+		cfg.blobs.Delete(r.Context(), storageKey)
+		responseError(w, http.StatusInternalServerError, "Error storing thumbnail", err)
+		return
+	}
+
+	media, err := cfg.db.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:           mediaID,
+		UserID:       userID,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Width:        int32(imgConfig.Width),
+		Height:       int32(imgConfig.Height),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+	})
+	if err != nil {
+		cfg.blobs.Delete(r.Context(), storageKey)
+		cfg.blobs.Delete(r.Context(), thumbnailKey)
+		responseError(w, http.StatusInternalServerError, "Error saving media", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, databaseMediaToChirpMedia(media))
+}
+
+func (cfg *apiConfig) handleGetMedia(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handleGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid media ID", err)
+		return
+	}
+
+	// Media of a hidden or deleted chirp is gone as far as readers know,
+	// and media not attached to a chirp yet is only for its uploader.
+	media, err := cfg.db.GetVisibleMedia(r.Context(), database.GetVisibleMediaParams{
+		ID:       mediaID,
+		ViewerID: cfg.optionalViewerID(r),
+	})
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Couldn't find media", err)
+		return
+	}
+
+	key := media.StorageKey
+	contentType := media.ContentType
+	etag := fmt.Sprintf(`"%s"`, media.ID)
+	if thumbnail {
+		key = media.ThumbnailKey
+		contentType = "image/png"
+		etag = fmt.Sprintf(`"%s-thumb"`, media.ID)
+	}
+
+	// Blobs never change, but a moderator can hide their chirp, so caches
+	// must check back every time. Visibility was checked above, so a
+	// matching ETag only saves sending the blob again.
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.blobs.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			responseError(w, http.StatusNotFound, "Couldn't find media", err)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Error reading media", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, blob)
+	if err != nil {
+		log.Printf("Error streaming media %s: %s", media.ID, err)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	maxMediaSize  = 5 << 20
+	maxChirpMedia = 4
+	thumbnailSize = 320
+)
+
+// maxMediaPixels caps the decoded size of an image, about 160 MB as RGBA,
+// whatever its shape. A small compressed file can still decode huge.
+const maxMediaPixels = 40_000_000
+
+var errMediaNotAttachable = errors.New("media is not the user's own unattached upload")
+
+var allowedMediaTypes = map[string]struct{}{
+	"image/png":  {},
+	"image/jpeg": {},
+	"image/gif":  {},
+}
+
+type ChirpMedia struct {
+	ID           uuid.UUID `json:"id"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func databaseMediaToChirpMedia(media database.Medium) ChirpMedia {
+	return ChirpMedia{
+		ID:           media.ID,
+		ContentType:  media.ContentType,
+		SizeBytes:    media.SizeBytes,
+		Width:        media.Width,
+		Height:       media.Height,
+		URL:          fmt.Sprintf("/api/media/%s", media.ID),
+		ThumbnailURL: fmt.Sprintf("/api/media/%s/thumbnail", media.ID),
+	}
+}
+
+// attachChirpMedia loads the media for a page of chirps in one query.
+// Hidden chirps keep their media to themselves.
+func (cfg *apiConfig) attachChirpMedia(ctx context.Context, chirps []Chirp) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		if !chirp.Hidden {
+			chirpIDs = append(chirpIDs, chirp.ID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+
+	dbMedia, err := cfg.db.ListMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	byChirp := map[uuid.UUID][]ChirpMedia{}
+	for _, media := range dbMedia {
+		byChirp[media.ChirpID.UUID] = append(byChirp[media.ChirpID.UUID], databaseMediaToChirpMedia(media))
+	}
+	for i := range chirps {
+		chirps[i].Media = byChirp[chirps[i].ID]
+	}
+
+	return nil
+}
+
+// deleteMediaBlobs removes the stored files of deleted media rows. It is
+// best effort since the rows are already gone.
+func (cfg *apiConfig) deleteMediaBlobs(ctx context.Context, deleted []database.Medium) {
+	for _, media := range deleted {
+		for _, key := range []string{media.StorageKey, media.ThumbnailKey} {
+			err := cfg.blobs.Delete(ctx, key)
+			if err != nil {
+				log.Printf("Error deleting blob %s: %s", key, err)
+			}
+		}
+	}
+}
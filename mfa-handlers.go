@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	type jsonResParams struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not find user", err)
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		responseError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating TOTP secret", err)
+		return
+	}
+
+	err = cfg.db.SetPendingTOTPSecret(r.Context(), database.SetPendingTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error saving TOTP secret", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, jsonResParams{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(secret, totpIssuer, user.Email),
+	})
+}
+
+func (cfg *apiConfig) handleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Code string `json:"code"`
+	}
+
+	type jsonResParams struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not find user", err)
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		responseError(w, http.StatusConflict, "Two-factor authentication is already enabled", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		responseError(w, http.StatusBadRequest, "Start enrollment before confirming it", nil)
+		return
+	}
+
+	counter, ok := auth.ValidateTOTP(strings.TrimSpace(params.Code), user.TotpSecret.String, time.Now())
+	if !ok {
+		responseError(w, http.StatusUnauthorized, "Invalid code", nil)
+		return
+	}
+
+	codes, hashes, err := cfg.generateRecoveryCodes()
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error creating recovery codes", err)
+		return
+	}
+
+	// Two-factor authentication is only on once the user has codes to
+	// recover with.
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.EnableTOTP(r.Context(), database.EnableTOTPParams{
+			ID:              user.ID,
+			TotpLastCounter: sql.NullInt64{Int64: counter, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(r.Context(), q, user.ID, hashes)
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error enabling two-factor authentication", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, jsonResParams{
+		RecoveryCodes: codes,
+	})
+}
+
+func (cfg *apiConfig) handleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not find user", err)
+		return
+	}
+
+	if !user.TotpEnabledAt.Valid {
+		responseError(w, http.StatusBadRequest, "Two-factor authentication is not enabled", nil)
+		return
+	}
+
+	// Wrong codes count against the login limits too, so a stolen access
+	// token can't be used to guess the second factor.
+	if !cfg.reserveLoginAttempt(w, r, user.Email) {
+		return
+	}
+
+	ok, err := cfg.verifySecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error checking code", err)
+		return
+	}
+	if !ok {
+		responseError(w, http.StatusUnauthorized, "Invalid code", nil)
+		return
+	}
+	cfg.releaseLoginAttempt(r, user.Email)
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.DisableTOTP(r.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+		return q.DeleteRecoveryCodes(r.Context(), user.ID)
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error disabling two-factor authentication", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	userID, err := auth.ValidateMFAToken(params.MFAToken, cfg.jwtKeys)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil || !user.TotpEnabledAt.Valid {
+		responseError(w, http.StatusUnauthorized, "Invalid or expired MFA token", err)
+		return
+	}
+
+	if user.SuspendedAt.Valid {
+		responseError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
+	// Wrong codes count against the same limits as wrong passwords, so a
+	// stolen password can't be used to guess the code.
+	if !cfg.reserveLoginAttempt(w, r, user.Email) {
+		return
+	}
+
+	ok, err := cfg.verifySecondFactor(r.Context(), user, params.Code, params.RecoveryCode)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error checking code", err)
+		return
+	}
+	if !ok {
+		responseError(w, http.StatusUnauthorized, "Invalid code", nil)
+		return
+	}
+	cfg.releaseLoginAttempt(r, user.Email)
+
+	cfg.completeLogin(w, r, user)
+}
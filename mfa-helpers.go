@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	totpIssuer        = "Chirpy"
+	recoveryCodeCount = 10
+)
+
+// verifySecondFactor checks a TOTP code, or a recovery code when no TOTP
+// code is given. Each TOTP time step and each recovery code is accepted
+// only once.
+func (cfg *apiConfig) verifySecondFactor(ctx context.Context, user database.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		counter, ok := auth.ValidateTOTP(strings.TrimSpace(code), user.TotpSecret.String, time.Now())
+		if !ok {
+			return false, nil
+		}
+
+		used, err := cfg.db.UseTOTPCounter(ctx, database.UseTOTPCounterParams{
+			Counter: counter,
+			ID:      user.ID,
+		})
+		if err != nil {
+			return false, err
+		}
+		return used == 1, nil
+	}
+
+	if recoveryCode == "" {
+		return false, nil
+	}
+	recoveryCode = strings.ToLower(strings.TrimSpace(recoveryCode))
+
+	codes, err := cfg.db.ListUnusedRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, stored := range codes {
+		if auth.CheckPasswordHash(recoveryCode, stored.CodeHash) != nil {
+			continue
+		}
+
+		used, err := cfg.db.UseRecoveryCode(ctx, stored.ID)
+		if err != nil {
+			return false, err
+		}
+		return used == 1, nil
+	}
+
+	return false, nil
+}
+
+// generateRecoveryCodes returns a fresh set of recovery codes to show the
+// user once, along with the hashes to store. Hashing is slow, so it happens
+// before any transaction is opened.
+func (cfg *apiConfig) generateRecoveryCodes() (codes, hashes []string, err error) {
+	codes, err = auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, code := range codes {
+		hash, err := auth.HashPassword(code, cfg.passwordParams)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return codes, hashes, nil
+}
+
+// replaceRecoveryCodes swaps the user's recovery codes for the given hashes
+// using q, so it can run inside a transaction.
+func replaceRecoveryCodes(ctx context.Context, q *database.Queries, userID uuid.UUID, hashes []string) error {
+	err := q.DeleteRecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateRecoveryCodes(ctx, database.CreateRecoveryCodesParams{
+		UserID:     userID,
+		CodeHashes: hashes,
+	})
+}
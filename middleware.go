@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"net/http"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
 )
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -10,3 +14,33 @@ func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// middlewareRequireRole only lets requests through when they come from a
+// login session whose user currently holds at least the required role. The
+// user is stored in the request context for the handler.
+func (cfg *apiConfig) middlewareRequireRole(required string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := cfg.authenticateUser(r, sessionOnly)
+		if err != nil {
+			responseTokenError(w, err)
+			return
+		}
+
+		if !auth.HasRole(user.Role, required) {
+			responseError(w, http.StatusForbidden, "Insufficient role", nil)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	}
+}
+
+// contextUser returns the user middlewareRequireRole authenticated.
+func contextUser(ctx context.Context) database.User {
+	user, _ := ctx.Value(userContextKey).(database.User)
+	return user
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/moderation"
+)
+
+const maxModerationWordLen = 100
+
+func (cfg *apiConfig) handleGetModerationWords(w http.ResponseWriter, r *http.Request) {
+	dbWords, err := cfg.db.ListModerationWords(r.Context())
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting moderation words", err)
+		return
+	}
+
+	words := []ModerationWord{}
+	for _, word := range dbWords {
+		words = append(words, databaseModerationWordToModerationWord(word))
+	}
+
+	jsonResponse(w, http.StatusOK, words)
+}
+
+func (cfg *apiConfig) handleCreateModerationWord(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Word      string `json:"word"`
+		MatchMode string `json:"match_mode"`
+		Action    string `json:"action"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	word := strings.ToLower(strings.TrimSpace(params.Word))
+	if word == "" || len(word) > maxModerationWordLen || strings.IndexFunc(word, unicode.IsSpace) != -1 {
+		responseError(w, http.StatusBadRequest, "word must be a single word", nil)
+		return
+	}
+	if params.MatchMode == "" {
+		params.MatchMode = string(moderation.MatchPunctuation)
+	}
+	if params.Action == "" {
+		params.Action = string(moderation.ActionMask)
+	}
+	if !validModerationParams(w, params.MatchMode, params.Action) {
+		return
+	}
+
+	created, err := cfg.db.CreateModerationWord(r.Context(), database.CreateModerationWordParams{
+		Word:      word,
+		MatchMode: params.MatchMode,
+		Action:    params.Action,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			responseError(w, http.StatusConflict, "Word is already on the list", err)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Error creating moderation word", err)
+		return
+	}
+
+	cfg.refreshWordFilter(r.Context())
+
+	jsonResponse(w, http.StatusCreated, databaseModerationWordToModerationWord(created))
+}
+
+func (cfg *apiConfig) handleUpdateModerationWord(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		MatchMode string `json:"match_mode"`
+		Action    string `json:"action"`
+	}
+
+	wordID, err := uuid.Parse(r.PathValue("wordID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild wordID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	if !validModerationParams(w, params.MatchMode, params.Action) {
+		return
+	}
+
+	updated, err := cfg.db.UpdateModerationWord(r.Context(), database.UpdateModerationWordParams{
+		ID:        wordID,
+		MatchMode: params.MatchMode,
+		Action:    params.Action,
+	})
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not find moderation word", err)
+		return
+	}
+
+	cfg.refreshWordFilter(r.Context())
+
+	jsonResponse(w, http.StatusOK, databaseModerationWordToModerationWord(updated))
+}
+
+func (cfg *apiConfig) handleDeleteModerationWord(w http.ResponseWriter, r *http.Request) {
+	wordID, err := uuid.Parse(r.PathValue("wordID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild wordID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteModerationWord(r.Context(), wordID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error deleting moderation word", err)
+		return
+	}
+	if deleted == 0 {
+		responseError(w, http.StatusNotFound, "Could not find moderation word", nil)
+		return
+	}
+
+	cfg.refreshWordFilter(r.Context())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleGetChirpFlags(w http.ResponseWriter, r *http.Request) {
+	dbFlags, err := cfg.db.ListChirpFlags(r.Context(), maxPageSize)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting chirp flags", err)
+		return
+	}
+
+	flags := []ChirpFlag{}
+	for _, flag := range dbFlags {
+		flags = append(flags, ChirpFlag{
+			ChirpID:   flag.ChirpID,
+			Word:      flag.Word,
+			CreatedAt: flag.CreatedAt,
+		})
+	}
+
+	jsonResponse(w, http.StatusOK, flags)
+}
+
+func validModerationParams(w http.ResponseWriter, matchMode, action string) bool {
+	if !moderation.ValidMatchMode(moderation.MatchMode(matchMode)) {
+		responseError(w, http.StatusBadRequest, "match_mode must be one of whole_word, punctuation, unicode or leetspeak", nil)
+		return false
+	}
+	if !moderation.ValidAction(moderation.Action(action)) {
+		responseError(w, http.StatusBadRequest, "action must be one of mask, reject or flag", nil)
+		return false
+	}
+
+	return true
+}
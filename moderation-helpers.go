@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/moderation"
+)
+
+const moderationCacheTTL = time.Minute
+
+type ModerationWord struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Word      string    `json:"word"`
+	MatchMode string    `json:"match_mode"`
+	Action    string    `json:"action"`
+}
+
+type ChirpFlag struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Word      string    `json:"word"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func databaseModerationWordToModerationWord(word database.ModerationWord) ModerationWord {
+	return ModerationWord{
+		ID:        word.ID,
+		CreatedAt: word.CreatedAt,
+		UpdatedAt: word.UpdatedAt,
+		Word:      word.Word,
+		MatchMode: word.MatchMode,
+		Action:    word.Action,
+	}
+}
+
+func (cfg *apiConfig) loadModerationRules(ctx context.Context) ([]moderation.Rule, error) {
+	words, err := cfg.db.ListModerationWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []moderation.Rule{}
+	for _, word := range words {
+		rules = append(rules, moderation.Rule{
+			Word:   word.Word,
+			Mode:   moderation.MatchMode(word.MatchMode),
+			Action: moderation.Action(word.Action),
+		})
+	}
+
+	return rules, nil
+}
+
+// refreshWordFilter reloads the cached filter after an admin change. The
+// change is already stored, so a failed reload is logged and the cache
+// falls back to its TTL.
+func (cfg *apiConfig) refreshWordFilter(ctx context.Context) {
+	_, err := cfg.wordFilter.Refresh(ctx)
+	if err != nil {
+		log.Printf("Error reloading moderation words: %s", err)
+	}
+}
+
+// flagChirp queues a chirp for review when it matched any flag words.
+func (cfg *apiConfig) flagChirp(ctx context.Context, chirpID uuid.UUID, result moderation.Result) {
+	if !result.Flagged {
+		return
+	}
+
+	words := []string{}
+	for _, match := range result.Matches {
+		if match.Action == moderation.ActionFlag {
+			words = append(words, match.Word)
+		}
+	}
+
+	err := cfg.db.FlagChirp(ctx, database.FlagChirpParams{
+		ChirpID: chirpID,
+		Words:   words,
+	})
+	if err != nil {
+		log.Printf("Error flagging chirp %s: %s", chirpID, err)
+	}
+}
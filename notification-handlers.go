@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Kind      string     `json:"kind"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+func (cfg *apiConfig) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	type jsonResParams struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeProfileRead)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	page, err := parseForwardPageParams(r)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(page.After)
+	dbNotifications, err := cfg.db.ListNotifications(r.Context(), database.ListNotificationsParams{
+		UserID:          userID,
+		UnreadOnly:      r.URL.Query().Get("unread") == "true",
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting notifications", err)
+		return
+	}
+
+	unreadCount, err := cfg.db.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error counting notifications", err)
+		return
+	}
+
+	notifications := []Notification{}
+	nextCursor := ""
+	for i, notification := range dbNotifications {
+		if i == int(page.Limit) {
+			last := dbNotifications[i-1]
+			nextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+
+		n := Notification{
+			ID:        notification.ID,
+			CreatedAt: notification.CreatedAt,
+			Kind:      notification.Kind,
+			ActorID:   notification.ActorID,
+			Read:      notification.ReadAt.Valid,
+		}
+		if notification.ChirpID.Valid {
+			n.ChirpID = &notification.ChirpID.UUID
+		}
+		if notification.ReadAt.Valid {
+			n.ReadAt = &notification.ReadAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, jsonResParams{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+	})
+}
+
+func (cfg *apiConfig) handleReadNotification(w http.ResponseWriter, r *http.Request) {
+	notificationID, err := uuid.Parse(r.PathValue("notificationID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid notification ID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeProfileWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	updated, err := cfg.db.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't mark notification as read", err)
+		return
+	}
+	if updated == 0 {
+		responseError(w, http.StatusNotFound, "Couldn't find notification", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleReadAllNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, auth.ScopeProfileWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	err = cfg.db.MarkAllNotificationsRead(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't mark notifications as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type pageParams struct {
+	Limit  int32
+	After  *pageCursor
+	Before *pageCursor
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	createdAtStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	return &pageCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}, nil
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	params := pageParams{
+		Limit: defaultPageSize,
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return pageParams{}, errors.New("limit must be a positive integer")
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+		params.Limit = int32(limit)
+	}
+
+	after := query.Get("after")
+	before := query.Get("before")
+	if after != "" && before != "" {
+		return pageParams{}, errors.New("after and before can't be used together")
+	}
+
+	var err error
+	if after != "" {
+		params.After, err = decodeCursor(after)
+		if err != nil {
+			return pageParams{}, err
+		}
+	}
+	if before != "" {
+		params.Before, err = decodeCursor(before)
+		if err != nil {
+			return pageParams{}, err
+		}
+	}
+
+	return params, nil
+}
+
+// parseForwardPageParams is parsePageParams for listings that can only be
+// walked newest first, so a before cursor is rejected.
+func parseForwardPageParams(r *http.Request) (pageParams, error) {
+	params, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		return pageParams{}, err
+	}
+	if params.Before != nil {
+		return pageParams{}, errors.New("before cursor is not supported here")
+	}
+
+	return params, nil
+}
+
+func cursorArgs(cursor *pageCursor) (sql.NullTime, uuid.NullUUID) {
+	if cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+
+	return sql.NullTime{Time: cursor.CreatedAt, Valid: true},
+		uuid.NullUUID{UUID: cursor.ID, Valid: true}
+}
+
+// setPaginationLinks writes an RFC 8288 Link header pointing at the next and
+// previous pages. Empty cursors are skipped.
+func setPaginationLinks(w http.ResponseWriter, r *http.Request, next, prev string) {
+	links := []string{}
+
+	buildLink := func(key, cursor, rel string) string {
+		query := r.URL.Query()
+		query.Del("after")
+		query.Del("before")
+		query.Set(key, cursor)
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+	}
+
+	if next != "" {
+		links = append(links, buildLink("after", next, "next"))
+	}
+	if prev != "" {
+		links = append(links, buildLink("before", prev, "prev"))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
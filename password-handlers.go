@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	passwordResetTTL = 30 * time.Minute
+	// passwordResetSendTimeout bounds the background work behind a reset
+	// request, mail delivery included.
+	passwordResetSendTimeout = time.Minute
+)
+
+var errInvalidResetToken = errors.New("reset token is invalid, used or expired")
+
+// handleForgotPassword mails a reset token to the account's address. It
+// answers the same way, and as fast, whether or not the email is registered,
+// so it can't be used to find out who has an account.
+func (cfg *apiConfig) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Error decoding parameters", err)
+		return
+	}
+	if params.Email == "" {
+		responseError(w, http.StatusBadRequest, "Empty email", nil)
+		return
+	}
+
+	if !cfg.reservePasswordResetAttempt(w, r, params.Email) {
+		return
+	}
+
+	// Looking up the account and mailing it happen after the response, so
+	// registered and unknown emails take the same time to answer.
+	go cfg.sendPasswordReset(params.Email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleResetPassword sets a new password with a token from
+// handleForgotPassword and signs the account out everywhere.
+func (cfg *apiConfig) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Error decoding parameters", err)
+		return
+	}
+
+	tokenHash := auth.HashToken(params.Token)
+	resetToken, err := cfg.db.GetPasswordResetToken(r.Context(), tokenHash)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid or expired reset token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), resetToken.UserID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting user", err)
+		return
+	}
+
+	// Check the policy before using up the token, so a rejected password
+	// can be retried with the same email.
+	if !cfg.validatePassword(w, params.Password, user.Email) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password, cfg.passwordParams)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error hashing password", err)
+		return
+	}
+
+	// The token is only used up if the password really changes, and every
+	// session and token from before goes with the old password.
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		used, err := q.UsePasswordResetToken(r.Context(), tokenHash)
+		if err != nil {
+			return err
+		}
+		if used == 0 {
+			return errInvalidResetToken
+		}
+
+		err = q.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			ID:             user.ID,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeUserRefreshTokens(r.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeUserAPITokens(r.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+
+		return q.InvalidatePasswordResetTokens(r.Context(), user.ID)
+	})
+	if errors.Is(err, errInvalidResetToken) {
+		responseError(w, http.StatusBadRequest, "Invalid or expired reset token", nil)
+		return
+	}
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	// Whoever reset the password owns the inbox, so lift any lockout.
+	err = cfg.clearLoginFailures(r.Context(), user.Email)
+	if err != nil {
+		log.Printf("Error clearing failed logins of user %s: %s", user.ID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
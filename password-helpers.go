@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/mail"
+)
+
+// sendPasswordReset mails a reset token to the account registered with
+// email, if there is one. It runs after the request has been answered, so
+// failures are only logged.
+func (cfg *apiConfig) sendPasswordReset(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+	defer cancel()
+
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting user for password reset: %s", err)
+		}
+		return
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error creating reset token for user %s: %s", user.ID, err)
+		return
+	}
+
+	// Only the newest reset email works.
+	err = cfg.db.InvalidatePasswordResetTokens(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error invalidating reset tokens of user %s: %s", user.ID, err)
+		return
+	}
+
+	err = cfg.db.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTTL),
+	})
+	if err != nil {
+		log.Printf("Error saving reset token for user %s: %s", user.ID, err)
+		return
+	}
+
+	err = cfg.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account.\n\n"+
+			"Your reset token is:\n\n%s\n\n"+
+			"It expires in %d minutes and can be used once. If you didn't ask for this, you can ignore this email.\n",
+			token, int(passwordResetTTL.Minutes())),
+	})
+	if err != nil {
+		log.Printf("Error sending password reset email to user %s: %s", user.ID, err)
+	}
+}
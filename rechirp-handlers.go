@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	original, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil || original.DeletedAt.Valid || original.HiddenAt.Valid {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	// Rechirping a rechirp boosts the chirp it points at.
+	rechirpOf := uuid.NullUUID{UUID: original.ID, Valid: true}
+	if original.RechirpOf.Valid {
+		rechirpOf = original.RechirpOf
+	}
+
+	rechirp, err := cfg.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:    userID,
+		RechirpOf: rechirpOf,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			responseError(w, http.StatusConflict, "Chirp already rechirped", nil)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Error creating rechirp", err)
+		return
+	}
+
+	chirps := []Chirp{databaseChirpToChirp(rechirp)}
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, chirps[0])
+}
+
+func (cfg *apiConfig) handleUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error deleting rechirp", err)
+		return
+	}
+	if deleted == 0 {
+		responseError(w, http.StatusNotFound, "Chirp wasn't rechirped", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleReportChirp(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	if _, ok := reportReasons[params.Reason]; !ok {
+		responseError(w, http.StatusBadRequest, "reason must be one of spam, harassment, hate, violence, sexual, self_harm, misinformation or other", nil)
+		return
+	}
+	if len(params.Details) > maxReportDetailsLen {
+		responseError(w, http.StatusBadRequest, "details too long", nil)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	if chirp.UserID == userID {
+		responseError(w, http.StatusBadRequest, "Can't report your own chirp", nil)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ReporterID: userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			responseError(w, http.StatusConflict, "You have already reported this chirp", err)
+			return
+		}
+		responseError(w, http.StatusInternalServerError, "Error creating report", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, databaseReportToReport(report))
+}
+
+func (cfg *apiConfig) handleGetReports(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = reportStatusOpen
+	case reportStatusOpen, reportStatusTriaged, reportStatusResolved, reportStatusDismissed:
+	default:
+		responseError(w, http.StatusBadRequest, "status must be one of open, triaged, resolved or dismissed", nil)
+		return
+	}
+
+	page, err := parseForwardPageParams(r)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(page.After)
+	dbReports, err := cfg.db.ListReports(r.Context(), database.ListReportsParams{
+		Status:          status,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting reports", err)
+		return
+	}
+
+	reports := []Report{}
+	nextCursor := ""
+	for i, report := range dbReports {
+		if i == int(page.Limit) {
+			last := dbReports[i-1]
+			nextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+		reports = append(reports, databaseReportToReport(report))
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, reports)
+}
+
+func (cfg *apiConfig) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild reportID", err)
+		return
+	}
+
+	report, err := cfg.db.GetReport(r.Context(), reportID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not get report", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, databaseReportToReport(report))
+}
+
+func (cfg *apiConfig) handleTriageReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild reportID", err)
+		return
+	}
+
+	moderatorID := contextUser(r.Context()).ID
+
+	report, err := cfg.db.TriageReport(r.Context(), database.TriageReportParams{
+		ID:          reportID,
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either there is no such report or it has moved past open.
+		_, err = cfg.db.GetReport(r.Context(), reportID)
+		if errors.Is(err, sql.ErrNoRows) {
+			responseError(w, http.StatusNotFound, "Could not get report", err)
+			return
+		}
+		if err != nil {
+			responseError(w, http.StatusInternalServerError, "Error getting report", err)
+			return
+		}
+		responseError(w, http.StatusConflict, "Report is not open", nil)
+		return
+	}
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error triaging report", err)
+		return
+	}
+
+	_, err = cfg.db.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+		ModeratorID: moderatorID,
+		Action:      moderationActionTriage,
+		ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+		ChirpID:     report.ChirpID,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error recording moderation action", err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, databaseReportToReport(report))
+}
+
+func (cfg *apiConfig) handleResolveReport(w http.ResponseWriter, r *http.Request) {
+	type jsonReqParams struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild reportID", err)
+		return
+	}
+
+	moderatorID := contextUser(r.Context()).ID
+
+	decoder := json.NewDecoder(r.Body)
+	params := jsonReqParams{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error decoding parameters", err)
+		return
+	}
+
+	status := reportStatusResolved
+	switch params.Action {
+	case moderationActionDismiss:
+		status = reportStatusDismissed
+	case moderationActionHideChirp, moderationActionDeleteChirp, moderationActionSuspendUser:
+	default:
+		responseError(w, http.StatusBadRequest, "action must be one of dismiss, hide_chirp, delete_chirp or suspend_user", nil)
+		return
+	}
+
+	report, err := cfg.db.GetReport(r.Context(), reportID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not get report", err)
+		return
+	}
+	if report.Status != reportStatusOpen && report.Status != reportStatusTriaged {
+		responseError(w, http.StatusConflict, "Report has already been closed", nil)
+		return
+	}
+
+	chirp := database.Chirp{}
+	targetUserID := uuid.NullUUID{}
+	if params.Action != moderationActionDismiss {
+		if !report.ChirpID.Valid {
+			responseError(w, http.StatusConflict, "Reported chirp no longer exists", nil)
+			return
+		}
+
+		chirp, err = cfg.db.GetChirp(r.Context(), report.ChirpID.UUID)
+		if err != nil {
+			responseError(w, http.StatusConflict, "Reported chirp no longer exists", err)
+			return
+		}
+		targetUserID = uuid.NullUUID{UUID: chirp.UserID, Valid: true}
+	}
+
+	// Closing the report, acting on the chirp and recording the action
+	// happen together or not at all.
+	var resolved database.ChirpReport
+	var deletedMedia []database.Medium
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		resolved, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			ID:          report.ID,
+			Status:      status,
+			Resolution:  sql.NullString{String: params.Action, Valid: true},
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if params.Action != moderationActionDismiss {
+			// Acting on a chirp settles every other open report about it.
+			// This goes first, since deleting the chirp clears their
+			// chirp_id.
+			err = q.ResolveChirpReports(r.Context(), database.ResolveChirpReportsParams{
+				ChirpID:     report.ChirpID,
+				Resolution:  sql.NullString{String: params.Action, Valid: true},
+				ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			deletedMedia, err = applyModerationAction(r.Context(), q, params.Action, chirp)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = q.CreateModerationAction(r.Context(), database.CreateModerationActionParams{
+			ModeratorID:  moderatorID,
+			Action:       params.Action,
+			ReportID:     uuid.NullUUID{UUID: report.ID, Valid: true},
+			ChirpID:      report.ChirpID,
+			TargetUserID: targetUserID,
+			Note:         params.Note,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		responseError(w, http.StatusConflict, "Report has already been closed", err)
+		return
+	}
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error resolving report", err)
+		return
+	}
+	cfg.deleteMediaBlobs(r.Context(), deletedMedia)
+
+	jsonResponse(w, http.StatusOK, databaseReportToReport(resolved))
+}
+
+func (cfg *apiConfig) handleGetModerationActions(w http.ResponseWriter, r *http.Request) {
+	dbActions, err := cfg.db.ListModerationActions(r.Context(), maxPageSize)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting moderation actions", err)
+		return
+	}
+
+	actions := []ModerationAction{}
+	for _, action := range dbActions {
+		actions = append(actions, databaseModerationActionToModerationAction(action))
+	}
+
+	jsonResponse(w, http.StatusOK, actions)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	reportStatusOpen      = "open"
+	reportStatusTriaged   = "triaged"
+	reportStatusResolved  = "resolved"
+	reportStatusDismissed = "dismissed"
+
+	moderationActionTriage      = "triage"
+	moderationActionDismiss     = "dismiss"
+	moderationActionHideChirp   = "hide_chirp"
+	moderationActionDeleteChirp = "delete_chirp"
+	moderationActionSuspendUser = "suspend_user"
+
+	maxReportDetailsLen = 1000
+)
+
+var reportReasons = map[string]struct{}{
+	"spam":           {},
+	"harassment":     {},
+	"hate":           {},
+	"violence":       {},
+	"sexual":         {},
+	"self_harm":      {},
+	"misinformation": {},
+	"other":          {},
+}
+
+type Report struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ChirpID     *uuid.UUID `json:"chirp_id"`
+	ReporterID  uuid.UUID  `json:"reporter_id"`
+	Reason      string     `json:"reason"`
+	Details     string     `json:"details"`
+	Status      string     `json:"status"`
+	Resolution  string     `json:"resolution,omitempty"`
+	ModeratorID *uuid.UUID `json:"moderator_id,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+type ModerationAction struct {
+	ID           uuid.UUID  `json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ModeratorID  uuid.UUID  `json:"moderator_id"`
+	Action       string     `json:"action"`
+	ReportID     *uuid.UUID `json:"report_id,omitempty"`
+	ChirpID      *uuid.UUID `json:"chirp_id,omitempty"`
+	TargetUserID *uuid.UUID `json:"target_user_id,omitempty"`
+	Note         string     `json:"note,omitempty"`
+}
+
+func databaseReportToReport(report database.ChirpReport) Report {
+	result := Report{
+		ID:         report.ID,
+		CreatedAt:  report.CreatedAt,
+		UpdatedAt:  report.UpdatedAt,
+		ReporterID: report.ReporterID,
+		Reason:     report.Reason,
+		Details:    report.Details,
+		Status:     report.Status,
+		Resolution: report.Resolution.String,
+	}
+	if report.ChirpID.Valid {
+		result.ChirpID = &report.ChirpID.UUID
+	}
+	if report.ModeratorID.Valid {
+		result.ModeratorID = &report.ModeratorID.UUID
+	}
+	if report.ResolvedAt.Valid {
+		result.ResolvedAt = &report.ResolvedAt.Time
+	}
+
+	return result
+}
+
+func databaseModerationActionToModerationAction(action database.ModerationAction) ModerationAction {
+	result := ModerationAction{
+		ID:          action.ID,
+		CreatedAt:   action.CreatedAt,
+		ModeratorID: action.ModeratorID,
+		Action:      action.Action,
+		Note:        action.Note,
+	}
+	if action.ReportID.Valid {
+		result.ReportID = &action.ReportID.UUID
+	}
+	if action.ChirpID.Valid {
+		result.ChirpID = &action.ChirpID.UUID
+	}
+	if action.TargetUserID.Valid {
+		result.TargetUserID = &action.TargetUserID.UUID
+	}
+
+	return result
+}
+
+// applyModerationAction carries out a resolve action against the reported
+// chirp using q, so it can share the resolve's transaction. delete_chirp
+// returns the media rows it removed so the caller can delete their blobs
+// after committing.
+func applyModerationAction(ctx context.Context, q *database.Queries, action string, chirp database.Chirp) ([]database.Medium, error) {
+	switch action {
+	case moderationActionHideChirp:
+		return nil, q.HideChirp(ctx, chirp.ID)
+	case moderationActionDeleteChirp:
+		return deleteChirp(ctx, q, chirp.ID)
+	case moderationActionSuspendUser:
+		err := q.SuspendUser(ctx, chirp.UserID)
+		if err != nil {
+			return nil, err
+		}
+		err = q.RevokeUserRefreshTokens(ctx, chirp.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, q.RevokeUserAPITokens(ctx, chirp.UserID)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChirpRevision struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Body      string    `json:"body"`
+}
+
+func (cfg *apiConfig) handleGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || chirp.HiddenAt.Valid {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	dbRevisions, err := cfg.db.ListChirpRevisions(r.Context(), chirp.ID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting revisions", err)
+		return
+	}
+
+	revisions := []ChirpRevision{}
+	for _, revision := range dbRevisions {
+		revisions = append(revisions, ChirpRevision{
+			ID:        revision.ID,
+			CreatedAt: revision.CreatedAt,
+			Body:      revision.Body,
+		})
+	}
+
+	jsonResponse(w, http.StatusOK, revisions)
+}
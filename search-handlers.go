@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+// ChirpSearchResult is a chirp matching a search. Snippet is HTML: the
+// chirp body is escaped and the matched words are wrapped in <mark> tags.
+type ChirpSearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (cfg *apiConfig) handleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	tsQuery, err := buildSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid search query", err)
+		return
+	}
+
+	queryUserID := r.URL.Query().Get("author_id")
+	authorID := uuid.NullUUID{}
+	if queryUserID != "" {
+		parsedID, err := uuid.Parse(queryUserID)
+		if err != nil {
+			responseError(w, http.StatusBadRequest, "Invallid user ID", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: parsedID, Valid: true}
+	}
+
+	// Results are paged by rank, so the after cursor is decoded separately
+	// and a before cursor isn't supported.
+	query := r.URL.Query()
+	after := query.Get("after")
+	query.Del("after")
+	page, err := parsePageParams(query)
+	if err == nil && page.Before != nil {
+		err = errors.New("before cursor is not supported here")
+	}
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+	limit := page.Limit
+
+	params := database.SearchChirpsParams{
+		Query:    tsQuery,
+		AuthorID: authorID,
+		PageSize: limit + 1,
+	}
+	if after != "" {
+		cursor, err := decodeSearchCursor(after)
+		if err != nil {
+			responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+			return
+		}
+		params.CursorRank = sql.NullFloat64{Float64: float64(cursor.Rank), Valid: true}
+		params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	dbResults, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error searching chirps", err)
+		return
+	}
+
+	results := []ChirpSearchResult{}
+	nextCursor := ""
+	for i, result := range dbResults {
+		if i == int(limit) {
+			last := dbResults[i-1]
+			nextCursor = encodeSearchCursor(last.Rank, last.CreatedAt, last.ID)
+			break
+		}
+		results = append(results, ChirpSearchResult{
+			Chirp: databaseChirpToChirp(database.Chirp{
+				ID:        result.ID,
+				CreatedAt: result.CreatedAt,
+				UpdatedAt: result.UpdatedAt,
+				Body:      result.Body,
+				UserID:    result.UserID,
+				InReplyTo: result.InReplyTo,
+				DeletedAt: result.DeletedAt,
+				LikeCount: result.LikeCount,
+				RechirpOf: result.RechirpOf,
+				QuoteOf:   result.QuoteOf,
+				EditedAt:  result.EditedAt,
+				HiddenAt:  result.HiddenAt,
+			}),
+			Rank:    result.Rank,
+			Snippet: result.Snippet,
+		})
+	}
+
+	chirps := make([]Chirp, 0, len(results))
+	for _, result := range results {
+		chirps = append(chirps, result.Chirp)
+	}
+	err = cfg.hydrateChirps(r.Context(), cfg.optionalViewerID(r), chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+	for i := range results {
+		results[i].Chirp = chirps[i]
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, results)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+type searchCursor struct {
+	Rank      float32
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func encodeSearchCursor(rank float32, createdAt time.Time, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor string) (*searchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed cursor")
+	}
+
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", err)
+	}
+
+	return &searchCursor{
+		Rank:      float32(rank),
+		CreatedAt: createdAt,
+		ID:        id,
+	}, nil
+}
+
+// buildSearchQuery turns user input into a to_tsquery expression. Quoted
+// text becomes a phrase match, a trailing * makes a prefix match and every
+// other word is ANDed together. Anything that isn't a letter or digit is
+// dropped so user input can never inject tsquery operators.
+func buildSearchQuery(q string) (string, error) {
+	terms := []string{}
+
+	for i, segment := range strings.Split(q, `"`) {
+		inPhrase := i%2 == 1
+
+		if inPhrase {
+			words := searchWords(segment)
+			if len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(segment) {
+			prefix := strings.HasSuffix(field, "*")
+			words := searchWords(field)
+			if len(words) == 0 {
+				continue
+			}
+			if prefix {
+				words[len(words)-1] += ":*"
+			}
+			terms = append(terms, words...)
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("search query has no searchable words")
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestBuildSearchQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"hello", "hello", true},
+		{"Hello World", "hello & world", true},
+		{"", "", false},
+		{"   ", "", false},
+		{"&|!:*()<->", "", false},
+		{"cat & dog", "cat & dog", true},
+		{"cat|dog", "cat & dog", true},
+		{"!cat", "cat", true},
+		{"(cat <-> dog)", "cat & dog", true},
+		{"cat:A", "cat & a", true},
+		{"ca*", "ca:*", true},
+		{"cat:*", "cat:*", true},
+		{"*", "", false},
+		{`"hello world"`, "(hello <-> world)", true},
+		{`"hello world" cat`, "(hello <-> world) & cat", true},
+		{`"a:* & !b"`, "(a <-> b)", true},
+		{`"unterminated phrase`, "(unterminated <-> phrase)", true},
+		{`""`, "", false},
+		{`"&"`, "", false},
+		{`'cat'`, "cat", true},
+		{`it's`, "it & s", true},
+		{`\'; DROP TABLE chirps; --`, "drop & table & chirps", true},
+		{"#golang", "golang", true},
+		{"#go-lang #Chirpy", "go & lang & chirpy", true},
+		{"café 日本語", "café & 日本語", true},
+	}
+
+	for _, test := range tests {
+		query, err := buildSearchQuery(test.input)
+		if (err == nil) != test.ok {
+			t.Errorf("buildSearchQuery(%q) err = %v, expected ok = %v", test.input, err, test.ok)
+			continue
+		}
+		if query != test.expected {
+			t.Errorf("buildSearchQuery(%q) = %q, expected %q", test.input, query, test.expected)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+// Session is one login: the chain of refresh tokens rotated from it.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+}
+
+func (cfg *apiConfig) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	dbSessions, err := cfg.db.ListSessions(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting sessions", err)
+		return
+	}
+
+	sessions := []Session{}
+	for _, session := range dbSessions {
+		sessions = append(sessions, Session{
+			ID:         session.FamilyID,
+			CreatedAt:  session.StartedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IpAddress,
+		})
+	}
+
+	jsonResponse(w, http.StatusOK, sessions)
+}
+
+// handleDeleteSession revokes the refresh tokens of one session. Access
+// tokens already issued to it stay valid until they expire.
+func (cfg *apiConfig) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid session ID", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeSession(r.Context(), database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
+		return
+	}
+	if revoked == 0 {
+		responseError(w, http.StatusNotFound, "Could not find session", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, sessionOnly)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	err = cfg.db.RevokeUserRefreshTokens(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+		return
+	}
+
+	err = cfg.db.RevokeUserAPITokens(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Couldn't revoke tokens", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
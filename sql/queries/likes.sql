@@ -0,0 +1,30 @@
+-- name: LikeChirp :exec
+WITH inserted AS (
+    INSERT INTO chirp_likes (chirp_id, user_id, created_at)
+    VALUES (
+        $1,
+        $2,
+        NOW()
+    )
+    ON CONFLICT DO NOTHING
+    RETURNING chirp_id
+)
+UPDATE chirps
+SET like_count = like_count + 1
+WHERE id IN (SELECT chirp_id FROM inserted);
+
+-- name: UnlikeChirp :exec
+WITH deleted AS (
+    DELETE FROM chirp_likes
+    WHERE chirp_id = $1
+    AND user_id = $2
+    RETURNING chirp_id
+)
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id IN (SELECT chirp_id FROM deleted);
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
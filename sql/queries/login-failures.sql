@@ -0,0 +1,41 @@
+-- name: ReserveLoginAttempt :one
+INSERT INTO login_failures (kind, subject, failures, last_failure_at)
+VALUES (sqlc.arg('kind'), sqlc.arg('subject'), 1, NOW())
+ON CONFLICT (kind, subject) DO UPDATE
+SET failures = CASE
+        WHEN login_failures.last_failure_at <= NOW() - make_interval(secs => sqlc.arg('reset_after')::float8) THEN 1
+        ELSE login_failures.failures + 1
+    END,
+    last_failure_at = NOW()
+WHERE login_failures.last_failure_at <= NOW() - make_interval(secs => sqlc.arg('reset_after')::float8)
+OR login_failures.last_failure_at + login_backoff(
+    login_failures.failures,
+    sqlc.arg('free_attempts')::integer,
+    sqlc.arg('base_delay')::float8,
+    sqlc.arg('max_delay')::float8
+) <= NOW()
+RETURNING *;
+
+-- name: GetLoginRetryAfter :one
+SELECT CEIL(EXTRACT(EPOCH FROM
+    last_failure_at + login_backoff(
+        failures,
+        sqlc.arg('free_attempts')::integer,
+        sqlc.arg('base_delay')::float8,
+        sqlc.arg('max_delay')::float8
+    ) - NOW()
+))::integer AS retry_after
+FROM login_failures
+WHERE kind = sqlc.arg('kind')
+AND subject = sqlc.arg('subject');
+
+-- name: ReleaseLoginAttempt :exec
+UPDATE login_failures
+SET failures = GREATEST(failures - 1, 0)
+WHERE kind = $1
+AND subject = $2;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE kind = $1
+AND subject = $2;
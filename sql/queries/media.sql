@@ -0,0 +1,41 @@
+-- name: CreateMedia :one
+INSERT INTO media (
+    id, created_at, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key
+) VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetVisibleMedia :one
+SELECT media.* FROM media
+LEFT JOIN chirps ON chirps.id = media.chirp_id
+WHERE media.id = sqlc.arg('id')
+AND (
+    (media.chirp_id IS NULL AND media.user_id = sqlc.narg('viewer_id'))
+    OR (chirps.id IS NOT NULL AND chirps.deleted_at IS NULL AND chirps.hidden_at IS NULL)
+);
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET chirp_id = sqlc.arg('chirp_id')::uuid
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND user_id = sqlc.arg('user_id')
+AND chirp_id IS NULL;
+
+-- name: ListMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY created_at ASC, id ASC;
+
+-- name: DeleteChirpMedia :many
+DELETE FROM media
+WHERE chirp_id = $1
+RETURNING *;
@@ -0,0 +1,12 @@
+-- name: CreateMentions :exec
+WITH mentioned AS (
+    INSERT INTO mentions (chirp_id, user_id, created_at)
+    SELECT sqlc.arg('chirp_id')::uuid, users.id, NOW() FROM users
+    WHERE users.username = ANY(sqlc.arg('usernames')::text[])
+    ON CONFLICT DO NOTHING
+    RETURNING user_id
+)
+INSERT INTO notifications (id, created_at, user_id, actor_id, kind, chirp_id)
+SELECT gen_random_uuid(), NOW(), mentioned.user_id, sqlc.arg('actor_id')::uuid, 'mention', sqlc.arg('chirp_id')::uuid
+FROM mentioned
+WHERE mentioned.user_id <> sqlc.arg('actor_id')::uuid;
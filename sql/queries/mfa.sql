@@ -0,0 +1,39 @@
+-- name: SetPendingTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled_at = NULL, totp_last_counter = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableTOTP :exec
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_counter = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_counter = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTOTPCounter :execrows
+UPDATE users
+SET totp_last_counter = sqlc.arg('counter')::bigint, updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND (totp_last_counter IS NULL OR totp_last_counter < sqlc.arg('counter')::bigint);
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (id, user_id, code_hash, created_at)
+SELECT gen_random_uuid(), sqlc.arg('user_id')::uuid, unnest(sqlc.arg('code_hashes')::text[]), NOW();
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: ListUnusedRecoveryCodes :many
+SELECT * FROM recovery_codes
+WHERE user_id = $1
+AND used_at IS NULL;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = NOW()
+WHERE id = $1
+AND used_at IS NULL;
@@ -0,0 +1,35 @@
+-- name: ListModerationWords :many
+SELECT * FROM moderation_words
+ORDER BY word ASC;
+
+-- name: CreateModerationWord :one
+INSERT INTO moderation_words (id, created_at, updated_at, word, match_mode, action)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: UpdateModerationWord :one
+UPDATE moderation_words
+SET match_mode = $2, action = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteModerationWord :execrows
+DELETE FROM moderation_words
+WHERE id = $1;
+
+-- name: FlagChirp :exec
+INSERT INTO chirp_flags (chirp_id, word, created_at)
+SELECT sqlc.arg('chirp_id')::uuid, unnest(sqlc.arg('words')::text[]), NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: ListChirpFlags :many
+SELECT * FROM chirp_flags
+ORDER BY created_at DESC, chirp_id ASC
+LIMIT $1;
@@ -0,0 +1,65 @@
+-- name: CreateReport :one
+INSERT INTO chirp_reports (id, created_at, updated_at, chirp_id, reporter_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM chirp_reports
+WHERE id = $1;
+
+-- name: ListReports :many
+SELECT * FROM chirp_reports
+WHERE status = sqlc.arg('status')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: TriageReport :one
+UPDATE chirp_reports
+SET status = 'triaged', moderator_id = $2, updated_at = NOW()
+WHERE id = $1
+AND status = 'open'
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE chirp_reports
+SET status = $2, resolution = $3, moderator_id = $4, resolved_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND status IN ('open', 'triaged')
+RETURNING *;
+
+-- name: ResolveChirpReports :exec
+UPDATE chirp_reports
+SET status = 'resolved', resolution = $2, moderator_id = $3, resolved_at = NOW(), updated_at = NOW()
+WHERE chirp_id = $1
+AND status IN ('open', 'triaged');
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, action, report_id, chirp_id, target_user_id, note)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
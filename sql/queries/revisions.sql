@@ -0,0 +1,21 @@
+-- name: EditChirp :one
+WITH previous AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), chirps.id, chirps.body, NOW() FROM chirps
+    WHERE chirps.id = sqlc.arg('id')
+    FOR UPDATE
+    RETURNING chirp_id
+)
+UPDATE chirps
+SET body = sqlc.arg('body'), edited_at = NOW(), updated_at = NOW()
+WHERE chirps.id = (SELECT chirp_id FROM previous)
+RETURNING *;
+
+-- name: ListChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions
+WHERE chirp_id = $1;
@@ -1,28 +1,73 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
--- name: DeleteAllUsers :exec
-DELETE FROM users;
+-- name: DeleteNonAdminUsers :exec
+DELETE FROM users
+WHERE role <> 'admin';
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
-WHERE email = $1;
+WHERE lower(email) = lower($1);
 
 -- name: UpdateUser :one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
-WHERE id = $3
+SET hashed_password = $1, username = COALESCE($3, username), updated_at = NOW()
+WHERE id = $2
 RETURNING *;
 
 -- name: UpgradeUser :exec
 UPDATE users
 set is_chirpy_red = TRUE, updated_at = NOW()
 WHERE id = $1;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GrantAdminByEmail :one
+UPDATE users
+SET role = 'admin', updated_at = NOW()
+WHERE lower(email) = lower($1)
+AND email_verified_at IS NOT NULL
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetPendingEmail :one
+UPDATE users
+SET pending_email = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ConfirmUserEmail :one
+UPDATE users
+SET email = sqlc.arg('email'),
+    email_verified_at = NOW(),
+    pending_email = CASE WHEN pending_email = sqlc.arg('email') THEN NULL ELSE pending_email END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND (email = sqlc.arg('email') OR pending_email = sqlc.arg('email'))
+RETURNING *;
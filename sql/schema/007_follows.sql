@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE follows (
+    follower_id UUID NOT NULL,
+    followed_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followed_id),
+    CHECK (follower_id <> followed_id),
+    FOREIGN KEY (follower_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (followed_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX follows_followed_id_created_at_idx ON follows (followed_id, created_at);
+
+-- +goose Down
+DROP TABLE follows;
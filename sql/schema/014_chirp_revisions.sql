@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE chirp_revisions (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_revisions_chirp_id_created_at_idx ON chirp_revisions (chirp_id, created_at);
+
+ALTER TABLE chirps
+ADD COLUMN edited_at TIMESTAMP DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN edited_at;
+
+DROP TABLE chirp_revisions;
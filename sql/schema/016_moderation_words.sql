@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE moderation_words (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    word TEXT NOT NULL UNIQUE,
+    match_mode TEXT NOT NULL
+    CHECK (match_mode IN ('whole_word', 'punctuation', 'unicode', 'leetspeak')),
+    action TEXT NOT NULL
+    CHECK (action IN ('mask', 'reject', 'flag'))
+);
+
+INSERT INTO moderation_words (id, created_at, updated_at, word, match_mode, action)
+VALUES
+    (gen_random_uuid(), NOW(), NOW(), 'kerfuffle', 'punctuation', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'sharbert', 'punctuation', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'fornax', 'punctuation', 'mask');
+
+CREATE TABLE chirp_flags (
+    chirp_id UUID NOT NULL,
+    word TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, word),
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_flags_created_at_idx ON chirp_flags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE moderation_words;
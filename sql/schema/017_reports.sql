@@ -0,0 +1,56 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP DEFAULT NULL;
+
+CREATE TABLE chirp_reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    chirp_id UUID DEFAULT NULL,
+    reporter_id UUID NOT NULL,
+    reason TEXT NOT NULL
+    CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open'
+    CHECK (status IN ('open', 'triaged', 'resolved', 'dismissed')),
+    resolution TEXT DEFAULT NULL,
+    moderator_id UUID DEFAULT NULL,
+    resolved_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (chirp_id, reporter_id),
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps(id) ON DELETE SET NULL,
+    FOREIGN KEY (reporter_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (moderator_id)
+    REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX chirp_reports_status_created_at_idx ON chirp_reports (status, created_at, id);
+
+-- Actions keep plain ids so the audit trail outlives the chirps and
+-- reports they refer to.
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    report_id UUID DEFAULT NULL,
+    chirp_id UUID DEFAULT NULL,
+    target_user_id UUID DEFAULT NULL,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX moderation_actions_created_at_idx ON moderation_actions (created_at);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE chirp_reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_at;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Promote the first admin by hand, e.g.
+-- UPDATE users SET role = 'admin' WHERE email = 'you@example.com';
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user'
+CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN role;
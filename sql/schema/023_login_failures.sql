@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE login_failures (
+    kind TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (kind, subject)
+);
+
+-- login_backoff is how long to refuse attempts after the given number of
+-- failures: nothing for the first free_attempts, then base_delay seconds
+-- doubling with each failure, capped at max_delay seconds.
+-- +goose StatementBegin
+CREATE FUNCTION login_backoff(
+    failures INTEGER,
+    free_attempts INTEGER,
+    base_delay DOUBLE PRECISION,
+    max_delay DOUBLE PRECISION
+) RETURNS INTERVAL AS $$
+    SELECT CASE
+        WHEN failures <= free_attempts THEN INTERVAL '0'
+        ELSE make_interval(secs => LEAST(max_delay, base_delay * power(2, LEAST(failures - free_attempts - 1, 60))))
+    END;
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION login_backoff;
+DROP TABLE login_failures;
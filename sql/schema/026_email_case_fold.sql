@@ -0,0 +1,29 @@
+-- +goose Up
+-- Accounts from before emails were lowercased can differ only in case. The
+-- verified, then oldest, one keeps the address; the others are moved to a
+-- placeholder on the reserved .invalid domain and have to be sorted out by
+-- hand.
+WITH ranked AS (
+    SELECT id, row_number() OVER (
+        PARTITION BY lower(email)
+        ORDER BY email_verified_at IS NULL, created_at, id
+    ) AS rank
+    FROM users
+)
+UPDATE users
+SET email = users.id::text || '@duplicate.invalid',
+    email_verified_at = NULL,
+    updated_at = NOW()
+FROM ranked
+WHERE ranked.id = users.id AND ranked.rank > 1;
+
+UPDATE users SET email = lower(email) WHERE email <> lower(email);
+UPDATE users SET pending_email = lower(pending_email) WHERE pending_email <> lower(pending_email);
+UPDATE email_verification_tokens SET email = lower(email) WHERE email <> lower(email);
+
+-- Emails are looked up by lower(email), and two accounts may not differ
+-- only in case.
+CREATE UNIQUE INDEX users_email_lower_key ON users (lower(email));
+
+-- +goose Down
+DROP INDEX users_email_lower_key;
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	defaultTrendingWindow = time.Hour * 24
+	maxTrendingWindow     = time.Hour * 24 * 7
+	defaultTrendingTags   = 10
+	maxTrendingTags       = 50
+)
+
+type TrendingTag struct {
+	Tag  string `json:"tag"`
+	Uses int64  `json:"uses"`
+}
+
+func (cfg *apiConfig) handleGetTagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		responseError(w, http.StatusBadRequest, "Empty tag", nil)
+		return
+	}
+
+	page, err := parseForwardPageParams(r)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(page.After)
+	dbChirps, err := cfg.db.ListChirpsByTag(r.Context(), database.ListChirpsByTagParams{
+		Tag:             tag,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting chirps for tag", err)
+		return
+	}
+
+	chirps := []Chirp{}
+	nextCursor := ""
+	for i, chirp := range dbChirps {
+		if i == int(page.Limit) {
+			last := dbChirps[i-1]
+			nextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+		chirps = append(chirps, databaseChirpToChirp(chirp))
+	}
+
+	err = cfg.hydrateChirps(r.Context(), cfg.optionalViewerID(r), chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, chirps)
+}
+
+// handleGetTrendingTags ranks tags by how often they were used within a
+// sliding window ending now, e.g. ?window=1h. The window defaults to a day.
+func (cfg *apiConfig) handleGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsedWindow, err := time.ParseDuration(windowStr)
+		if err != nil || parsedWindow <= 0 {
+			responseError(w, http.StatusBadRequest, "window must be a positive duration like 1h", err)
+			return
+		}
+		window = min(parsedWindow, maxTrendingWindow)
+	}
+
+	limit := defaultTrendingTags
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 {
+			responseError(w, http.StatusBadRequest, "limit must be a positive integer", err)
+			return
+		}
+		limit = min(parsedLimit, maxTrendingTags)
+	}
+
+	dbTags, err := cfg.db.GetTrendingTags(r.Context(), database.GetTrendingTagsParams{
+		Since:   time.Now().UTC().Add(-window),
+		MaxTags: int32(limit),
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting trending tags", err)
+		return
+	}
+
+	tags := []TrendingTag{}
+	for _, tag := range dbTags {
+		tags = append(tags, TrendingTag{
+			Tag:  tag.Tag,
+			Uses: tag.Uses,
+		})
+	}
+
+	jsonResponse(w, http.StatusOK, tags)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+	maxThreadAncestors = 50
+	maxThreadReplies   = 500
+)
+
+type ChirpReply struct {
+	Chirp
+	Replies []ChirpReply `json:"replies"`
+}
+
+type ChirpThread struct {
+	Ancestors []Chirp      `json:"ancestors"`
+	Chirp     Chirp        `json:"chirp"`
+	Replies   []ChirpReply `json:"replies"`
+}
+
+func (cfg *apiConfig) handleGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invaild chirpID", err)
+		return
+	}
+
+	depth := defaultThreadDepth
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 0 {
+			responseError(w, http.StatusBadRequest, "depth must be a non-negative integer", err)
+			return
+		}
+		if depth > maxThreadDepth {
+			depth = maxThreadDepth
+		}
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		responseError(w, http.StatusNotFound, "Could not get chirp", err)
+		return
+	}
+
+	dbAncestors, err := cfg.db.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ChirpID:  chirp.ID,
+		MaxDepth: maxThreadAncestors,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting thread ancestors", err)
+		return
+	}
+
+	threadChirps := []Chirp{}
+	for _, ancestor := range dbAncestors {
+		threadChirps = append(threadChirps, databaseChirpToChirp(database.Chirp{
+			ID:        ancestor.ID,
+			CreatedAt: ancestor.CreatedAt,
+			UpdatedAt: ancestor.UpdatedAt,
+			Body:      ancestor.Body,
+			UserID:    ancestor.UserID,
+			InReplyTo: ancestor.InReplyTo,
+			DeletedAt: ancestor.DeletedAt,
+			LikeCount: ancestor.LikeCount,
+			RechirpOf: ancestor.RechirpOf,
+			QuoteOf:   ancestor.QuoteOf,
+			EditedAt:  ancestor.EditedAt,
+			HiddenAt:  ancestor.HiddenAt,
+		}))
+	}
+	threadChirps = append(threadChirps, databaseChirpToChirp(chirp))
+
+	if depth > 0 {
+		dbReplies, err := cfg.db.GetChirpReplies(r.Context(), database.GetChirpRepliesParams{
+			ChirpID:    chirp.ID,
+			MaxDepth:   int32(depth),
+			MaxReplies: maxThreadReplies,
+		})
+		if err != nil {
+			responseError(w, http.StatusInternalServerError, "Error getting thread replies", err)
+			return
+		}
+
+		for _, reply := range dbReplies {
+			threadChirps = append(threadChirps, databaseChirpToChirp(database.Chirp{
+				ID:        reply.ID,
+				CreatedAt: reply.CreatedAt,
+				UpdatedAt: reply.UpdatedAt,
+				Body:      reply.Body,
+				UserID:    reply.UserID,
+				InReplyTo: reply.InReplyTo,
+				DeletedAt: reply.DeletedAt,
+				LikeCount: reply.LikeCount,
+				RechirpOf: reply.RechirpOf,
+				QuoteOf:   reply.QuoteOf,
+				EditedAt:  reply.EditedAt,
+				HiddenAt:  reply.HiddenAt,
+			}))
+		}
+	}
+
+	err = cfg.hydrateChirps(r.Context(), cfg.optionalViewerID(r), threadChirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	children := map[uuid.UUID][]Chirp{}
+	for _, reply := range threadChirps[len(dbAncestors)+1:] {
+		children[*reply.InReplyTo] = append(children[*reply.InReplyTo], reply)
+	}
+
+	jsonResponse(w, http.StatusOK, ChirpThread{
+		Ancestors: threadChirps[:len(dbAncestors)],
+		Chirp:     threadChirps[len(dbAncestors)],
+		Replies:   buildReplyTree(children, chirp.ID),
+	})
+}
+
+func buildReplyTree(children map[uuid.UUID][]Chirp, parentID uuid.UUID) []ChirpReply {
+	replies := []ChirpReply{}
+	for _, child := range children[parentID] {
+		replies = append(replies, ChirpReply{
+			Chirp:   child,
+			Replies: buildReplyTree(children, child.ID),
+		})
+	}
+
+	return replies
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+// Kinds of subject that attempts are counted against. Password reset and
+// email verification requests are counted in the same table as failed
+// logins, under their own kinds.
+const (
+	loginFailureAccount    = "account"
+	loginFailureIP         = "ip"
+	passwordResetEmail     = "reset_email"
+	passwordResetIP        = "reset_ip"
+	emailVerificationUser  = "verify_user"
+	emailVerificationEmail = "verify_email"
+)
+
+// loginBackoff describes how long to refuse attempts after repeated
+// failures. The first freeAttempts failures cost nothing; each one after
+// that doubles the wait, starting at baseDelay and capped at maxDelay, which
+// acts as the lockout period. A subject with no failures for resetAfter
+// starts over. The arithmetic itself runs in the database, against its own
+// clock.
+type loginBackoff struct {
+	freeAttempts int32
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	resetAfter   time.Duration
+}
+
+// loginBackoffs are the limits for each kind of subject. An IP gets more
+// free attempts than an account since many users can share one address.
+// Every password reset and email verification request counts, since each
+// one sends an email.
+var loginBackoffs = map[string]loginBackoff{
+	loginFailureAccount: {
+		freeAttempts: 5,
+		baseDelay:    time.Second,
+		maxDelay:     15 * time.Minute,
+		resetAfter:   time.Hour,
+	},
+	loginFailureIP: {
+		freeAttempts: 20,
+		baseDelay:    time.Second,
+		maxDelay:     time.Hour,
+		resetAfter:   time.Hour,
+	},
+	passwordResetEmail: {
+		freeAttempts: 3,
+		baseDelay:    time.Minute,
+		maxDelay:     time.Hour,
+		resetAfter:   time.Hour,
+	},
+	passwordResetIP: {
+		freeAttempts: 10,
+		baseDelay:    time.Minute,
+		maxDelay:     time.Hour,
+		resetAfter:   time.Hour,
+	},
+	emailVerificationUser: {
+		freeAttempts: 3,
+		baseDelay:    time.Minute,
+		maxDelay:     time.Hour,
+		resetAfter:   time.Hour,
+	},
+	emailVerificationEmail: {
+		freeAttempts: 3,
+		baseDelay:    time.Minute,
+		maxDelay:     time.Hour,
+		resetAfter:   time.Hour,
+	},
+}
+
+type loginSubject struct {
+	kind    string
+	subject string
+}
+
+// loginSubjects are the keys a login attempt counts against: the account,
+// by email so unknown addresses are throttled the same way, and the client
+// IP.
+func loginSubjects(r *http.Request, email string) []loginSubject {
+	return []loginSubject{
+		{kind: loginFailureAccount, subject: strings.ToLower(email)},
+		{kind: loginFailureIP, subject: clientIP(r)},
+	}
+}
+
+// reserveLoginAttempt counts the attempt as a failure against the account
+// and the client IP before the password is checked, so concurrent guesses
+// can't all slip in under the limit. It answers 429 with Retry-After and
+// returns false while either is backing off. Callers hand the reservation
+// back with releaseLoginAttempt once the credentials check out.
+func (cfg *apiConfig) reserveLoginAttempt(w http.ResponseWriter, r *http.Request, email string) bool {
+	return cfg.reserveAttempt(w, r, loginSubjects(r, email), "Too many failed login attempts, try again later")
+}
+
+// reservePasswordResetAttempt counts a password reset request against the
+// email and the client IP, whether or not the email is registered.
+func (cfg *apiConfig) reservePasswordResetAttempt(w http.ResponseWriter, r *http.Request, email string) bool {
+	return cfg.reserveAttempt(w, r, []loginSubject{
+		{kind: passwordResetEmail, subject: strings.ToLower(email)},
+		{kind: passwordResetIP, subject: clientIP(r)},
+	}, "Too many password reset requests, try again later")
+}
+
+// reserveEmailVerificationAttempt counts a verification email against the
+// address it goes to and, once the account exists, the user asking for it,
+// so no one can point a stream of mail at someone else's inbox.
+func (cfg *apiConfig) reserveEmailVerificationAttempt(w http.ResponseWriter, r *http.Request, userID uuid.NullUUID, email string) bool {
+	subjects := []loginSubject{
+		{kind: emailVerificationEmail, subject: strings.ToLower(email)},
+	}
+	if userID.Valid {
+		subjects = append(subjects, loginSubject{kind: emailVerificationUser, subject: userID.UUID.String()})
+	}
+
+	return cfg.reserveAttempt(w, r, subjects, "Too many verification emails, try again later")
+}
+
+func (cfg *apiConfig) reserveAttempt(w http.ResponseWriter, r *http.Request, subjects []loginSubject, msg string) bool {
+	reserved := []loginSubject{}
+
+	for _, subject := range subjects {
+		backoff := loginBackoffs[subject.kind]
+		_, err := cfg.db.ReserveLoginAttempt(r.Context(), database.ReserveLoginAttemptParams{
+			Kind:         subject.kind,
+			Subject:      subject.subject,
+			ResetAfter:   backoff.resetAfter.Seconds(),
+			FreeAttempts: backoff.freeAttempts,
+			BaseDelay:    backoff.baseDelay.Seconds(),
+			MaxDelay:     backoff.maxDelay.Seconds(),
+		})
+		if err == nil {
+			reserved = append(reserved, subject)
+			continue
+		}
+
+		cfg.releaseLoginSubjects(r.Context(), reserved)
+		if !errors.Is(err, sql.ErrNoRows) {
+			responseError(w, http.StatusInternalServerError, "Error checking attempts", err)
+			return false
+		}
+
+		retryAfter, err := cfg.db.GetLoginRetryAfter(r.Context(), database.GetLoginRetryAfterParams{
+			FreeAttempts: backoff.freeAttempts,
+			BaseDelay:    backoff.baseDelay.Seconds(),
+			MaxDelay:     backoff.maxDelay.Seconds(),
+			Kind:         subject.kind,
+			Subject:      subject.subject,
+		})
+		if err != nil {
+			log.Printf("Error getting retry time for %s %s: %s", subject.kind, subject.subject, err)
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(max(retryAfter, 1))))
+		responseError(w, http.StatusTooManyRequests, msg, nil)
+		return false
+	}
+
+	return true
+}
+
+// releaseLoginAttempt takes back the failure reserved for an attempt that
+// turned out to be good.
+func (cfg *apiConfig) releaseLoginAttempt(r *http.Request, email string) {
+	cfg.releaseLoginSubjects(r.Context(), loginSubjects(r, email))
+}
+
+func (cfg *apiConfig) releaseLoginSubjects(ctx context.Context, subjects []loginSubject) {
+	for _, subject := range subjects {
+		err := cfg.db.ReleaseLoginAttempt(ctx, database.ReleaseLoginAttemptParams{
+			Kind:    subject.kind,
+			Subject: subject.subject,
+		})
+		if err != nil {
+			log.Printf("Error releasing login attempt for %s %s: %s", subject.kind, subject.subject, err)
+		}
+	}
+}
+
+// clearLoginFailures unlocks an account. The IP keeps its count, so one
+// good login can't be used to keep guessing other accounts.
+func (cfg *apiConfig) clearLoginFailures(ctx context.Context, email string) error {
+	return cfg.db.ClearLoginFailures(ctx, database.ClearLoginFailuresParams{
+		Kind:    loginFailureAccount,
+		Subject: strings.ToLower(email),
+	})
+}
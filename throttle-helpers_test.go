@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+// testDB connects to the migrated database in TEST_DB_URL, skipping the
+// test when there is none.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestLoginBackoffsCoverEveryKind(t *testing.T) {
+	kinds := []string{
+		loginFailureAccount,
+		loginFailureIP,
+		passwordResetEmail,
+		passwordResetIP,
+		emailVerificationUser,
+		emailVerificationEmail,
+	}
+
+	for _, kind := range kinds {
+		backoff, ok := loginBackoffs[kind]
+		if !ok {
+			t.Errorf("%s: no backoff configured", kind)
+			continue
+		}
+		if backoff.baseDelay <= 0 || backoff.baseDelay > backoff.maxDelay {
+			t.Errorf("%s: baseDelay %s should be positive and at most maxDelay %s", kind, backoff.baseDelay, backoff.maxDelay)
+		}
+		// A lockout longer than resetAfter would be forgotten before it
+		// ends.
+		if backoff.maxDelay > backoff.resetAfter {
+			t.Errorf("%s: maxDelay %s outlasts resetAfter %s", kind, backoff.maxDelay, backoff.resetAfter)
+		}
+	}
+}
+
+func TestLoginBackoffDelay(t *testing.T) {
+	db := testDB(t)
+
+	tests := []struct {
+		failures int32
+		expected time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{8, 16 * time.Second},
+		{10, time.Minute},
+		{1000, time.Minute},
+	}
+
+	for _, test := range tests {
+		var seconds float64
+		err := db.QueryRow(
+			"SELECT EXTRACT(EPOCH FROM login_backoff($1, $2, $3, $4))::float8",
+			test.failures, 3, time.Second.Seconds(), time.Minute.Seconds(),
+		).Scan(&seconds)
+		if err != nil {
+			t.Fatalf("login_backoff(%d) returned an err: %v", test.failures, err)
+		}
+
+		if got := time.Duration(seconds * float64(time.Second)); got != test.expected {
+			t.Errorf("login_backoff(%d) = %s, expected %s", test.failures, got, test.expected)
+		}
+	}
+}
+
+func TestReserveLoginAttempt(t *testing.T) {
+	db := database.New(testDB(t))
+	ctx := context.Background()
+
+	backoff := loginBackoff{
+		freeAttempts: 2,
+		baseDelay:    time.Hour,
+		maxDelay:     time.Hour,
+		resetAfter:   time.Hour,
+	}
+	subject := uuid.NewString()
+	t.Cleanup(func() {
+		db.ClearLoginFailures(ctx, database.ClearLoginFailuresParams{Kind: loginFailureAccount, Subject: subject})
+	})
+
+	reserve := func() error {
+		_, err := db.ReserveLoginAttempt(ctx, database.ReserveLoginAttemptParams{
+			Kind:         loginFailureAccount,
+			Subject:      subject,
+			ResetAfter:   backoff.resetAfter.Seconds(),
+			FreeAttempts: backoff.freeAttempts,
+			BaseDelay:    backoff.baseDelay.Seconds(),
+			MaxDelay:     backoff.maxDelay.Seconds(),
+		})
+		return err
+	}
+
+	// The free attempts and the one that uses up the last of them go
+	// through; after that the subject waits out baseDelay.
+	for i := 0; i <= int(backoff.freeAttempts); i++ {
+		if err := reserve(); err != nil {
+			t.Fatalf("attempt %d returned an err: %v", i+1, err)
+		}
+	}
+	if err := reserve(); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("attempt past the limit returned %v, expected sql.ErrNoRows", err)
+	}
+
+	retryAfter, err := db.GetLoginRetryAfter(ctx, database.GetLoginRetryAfterParams{
+		FreeAttempts: backoff.freeAttempts,
+		BaseDelay:    backoff.baseDelay.Seconds(),
+		MaxDelay:     backoff.maxDelay.Seconds(),
+		Kind:         loginFailureAccount,
+		Subject:      subject,
+	})
+	if err != nil {
+		t.Fatalf("GetLoginRetryAfter returned an err: %v", err)
+	}
+	if retryAfter <= 0 || retryAfter > int32(backoff.baseDelay.Seconds()) {
+		t.Errorf("GetLoginRetryAfter = %d, expected up to %d", retryAfter, int32(backoff.baseDelay.Seconds()))
+	}
+
+	// Giving an attempt back lets the next one through.
+	err = db.ReleaseLoginAttempt(ctx, database.ReleaseLoginAttemptParams{Kind: loginFailureAccount, Subject: subject})
+	if err != nil {
+		t.Fatalf("ReleaseLoginAttempt returned an err: %v", err)
+	}
+	if err := reserve(); err != nil {
+		t.Errorf("attempt after a release returned an err: %v", err)
+	}
+}
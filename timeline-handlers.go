@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/auth"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsRead)
+	if err != nil {
+		responseTokenError(w, err)
+		return
+	}
+
+	page, err := parseForwardPageParams(r)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, "Invalid pagination parameters", err)
+		return
+	}
+
+	cursorCreatedAt, cursorID := cursorArgs(page.After)
+	dbChirps, err := cfg.db.ListTimeline(r.Context(), database.ListTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	})
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting timeline", err)
+		return
+	}
+
+	chirps := []Chirp{}
+	nextCursor := ""
+	for i, chirp := range dbChirps {
+		if i == int(page.Limit) {
+			last := dbChirps[i-1]
+			nextCursor = encodeCursor(last.CreatedAt, last.ID)
+			break
+		}
+		chirps = append(chirps, databaseChirpToChirp(chirp))
+	}
+
+	err = cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error loading chirp details", err)
+		return
+	}
+
+	setPaginationLinks(w, r, nextCursor, "")
+	jsonResponse(w, http.StatusOK, chirps)
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,17 +14,29 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	IsChirpyRead bool      `json:"is_chirpy_red"`
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	IsChirpyRead  bool      `json:"is_chirpy_red"`
+	Username      string    `json:"username,omitempty"`
+	Role          string    `json:"role,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	PendingEmail  string    `json:"pending_email,omitempty"`
+}
+
+// PublicUser is what anyone may see of another user's account.
+type PublicUser struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Username  string    `json:"username,omitempty"`
 }
 
 func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	type jsonReqParams struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Username string `json:"username"`
 	}
 
 	type jsonResParams struct {
@@ -40,29 +55,50 @@ func (cfg *apiConfig) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	email, err := normalizeEmail(params.Email)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	username, err := normalizeUsername(params.Username)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if !cfg.validatePassword(w, params.Password, email) {
+		return
+	}
+
+	if !cfg.reserveEmailVerificationAttempt(w, r, uuid.NullUUID{}, email) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password, cfg.passwordParams)
 	if err != nil {
 		responseError(w, http.StatusInternalServerError, "Error hashing password", err)
 		return
 	}
 
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
+		Username:       username,
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			responseError(w, http.StatusConflict, userConflictMessage(err), err)
+			return
+		}
 		responseError(w, http.StatusInternalServerError, "Error creating user", err)
 		return
 	}
 
+	go cfg.sendEmailVerification(user.ID, user.Email)
+
 	jsonResponse(w, http.StatusCreated, jsonResParams{
-		User: User{
-			ID:           user.ID,
-			CreatedAt:    user.CreatedAt,
-			UpdatedAt:    user.UpdatedAt,
-			Email:        user.Email,
-			IsChirpyRead: user.IsChirpyRed,
-		},
+		User: databaseUserToUser(user),
 	})
 
 }
@@ -71,6 +107,7 @@ func (cfg *apiConfig) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
 	type jsonReqParams struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Username string `json:"username"`
 	}
 
 	type jsonResParams struct {
@@ -89,38 +126,93 @@ func (cfg *apiConfig) handleUserUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userID, err := cfg.authenticate(r, sessionOnly)
 	if err != nil {
-		responseError(w, http.StatusUnauthorized, "Couldn't validate token", err)
+		responseTokenError(w, err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.seceret)
+	email, err := normalizeEmail(params.Email)
 	if err != nil {
-		responseError(w, http.StatusUnauthorized, "Couldn't validate token", err)
+		responseError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	username, err := normalizeUsername(params.Username)
+	if err != nil {
+		responseError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error getting user", err)
+		return
+	}
+
+	// Accounts from before emails were lowercased may hold mixed case.
+	sameEmail := strings.EqualFold(email, user.Email)
+	changingEmail := !sameEmail && email != user.PendingEmail.String
+	if changingEmail {
+		_, err = cfg.db.GetUserByEmail(r.Context(), email)
+		if err == nil {
+			responseError(w, http.StatusConflict, "Email already registered", nil)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			responseError(w, http.StatusInternalServerError, "Error checking email", err)
+			return
+		}
+	}
+
+	if !cfg.validatePassword(w, params.Password, user.Email) {
+		return
+	}
+
+	if changingEmail && !cfg.reserveEmailVerificationAttempt(w, r, uuid.NullUUID{UUID: userID, Valid: true}, email) {
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(params.Password, cfg.passwordParams)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error hashing password", err)
+		return
+	}
 
 	updatedUser, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		Email:          params.Email,
 		HashedPassword: hashedPassword,
 		ID:             userID,
+		Username:       username,
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			responseError(w, http.StatusConflict, userConflictMessage(err), err)
+			return
+		}
 		responseError(w, http.StatusInternalServerError, "Couldn't update user info", err)
 		return
 	}
 
+	// A new email only replaces the current one once it is confirmed.
+	// Asking for the current email again drops any pending change.
+	if changingEmail || (sameEmail && user.PendingEmail.Valid) {
+		pendingEmail := sql.NullString{String: email, Valid: changingEmail}
+		updatedUser, err = cfg.db.SetPendingEmail(r.Context(), database.SetPendingEmailParams{
+			ID:           userID,
+			PendingEmail: pendingEmail,
+		})
+		if err != nil {
+			responseError(w, http.StatusInternalServerError, "Couldn't update email", err)
+			return
+		}
+	}
+
+	if changingEmail {
+		go cfg.sendEmailVerification(userID, email)
+	}
+
 	jsonResponse(w, http.StatusOK, jsonResParams{
-		User: User{
-			ID:           updatedUser.ID,
-			UpdatedAt:    updatedUser.UpdatedAt,
-			CreatedAt:    updatedUser.CreatedAt,
-			Email:        updatedUser.Email,
-			IsChirpyRead: updatedUser.IsChirpyRed,
-		},
+		User: databaseUserToUser(updatedUser),
 	})
 
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/database"
+	"github.com/itsMe-ThatOneGuy/go-chirpy/internal/passwords"
+	"github.com/lib/pq"
+)
+
+const maxEmailLen = 254
+
+var (
+	usernameRegexp = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+	mentionRegexp  = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@])@([A-Za-z0-9_]{3,30})\b`)
+)
+
+func databaseUserToUser(user database.User) User {
+	return User{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		IsChirpyRead:  user.IsChirpyRed,
+		Username:      user.Username.String,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		PendingEmail:  user.PendingEmail.String,
+	}
+}
+
+// normalizeUsername lowercases a requested username and checks it against
+// the handle rules. An empty username is allowed and maps to NULL.
+func normalizeUsername(username string) (sql.NullString, error) {
+	if username == "" {
+		return sql.NullString{}, nil
+	}
+
+	username = strings.ToLower(username)
+	if !usernameRegexp.MatchString(username) {
+		return sql.NullString{}, errors.New("username must be 3-30 letters, digits or underscores")
+	}
+
+	return sql.NullString{String: username, Valid: true}, nil
+}
+
+// normalizeEmail trims and lowercases a requested email and checks that it
+// is a bare address like name@example.com, without a display name or angle
+// brackets.
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	invalid := errors.New("email must be a valid address like name@example.com")
+	if len(email) > maxEmailLen {
+		return "", invalid
+	}
+
+	addr, err := netmail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", invalid
+	}
+
+	// ParseAddress accepts single label domains such as "localhost", which
+	// for a signup is almost always a typo.
+	_, domain, _ := strings.Cut(email, "@")
+	if !strings.Contains(domain, ".") {
+		return "", invalid
+	}
+
+	// Emails are matched without regard to case, so one person can't
+	// register both Bob@ and bob@.
+	return strings.ToLower(email), nil
+}
+
+// extractMentions returns the distinct @usernames in body, lowercased and
+// without the leading @.
+func extractMentions(body string) []string {
+	usernames := []string{}
+	seen := map[string]struct{}{}
+
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		username := strings.ToLower(match[1])
+		if _, ok := seen[username]; ok {
+			continue
+		}
+		seen[username] = struct{}{}
+		usernames = append(usernames, username)
+	}
+
+	return usernames
+}
+
+// validatePassword checks password against the password policy and answers
+// with every violation if it fails.
+func (cfg *apiConfig) validatePassword(w http.ResponseWriter, password, email string) bool {
+	violations, err := cfg.passwordPolicy.Check(password, email)
+	if err != nil {
+		responseError(w, http.StatusInternalServerError, "Error checking password", err)
+		return false
+	}
+	if violations == nil {
+		return true
+	}
+
+	type passwordPolicyError struct {
+		Error      string                `json:"error"`
+		Violations []passwords.Violation `json:"violations"`
+	}
+
+	jsonResponse(w, http.StatusBadRequest, passwordPolicyError{
+		Error:      "Password does not meet the password policy",
+		Violations: violations,
+	})
+	return false
+}
+
+// grantBootstrapAdmin makes the account registered with ADMIN_EMAIL an
+// admin, so a fresh install has someone who can grant roles. Only a
+// verified address counts, or whoever signed up first with it would win.
+// It runs at startup and again whenever an address is verified.
+func (cfg *apiConfig) grantBootstrapAdmin(ctx context.Context, email string) (database.User, bool) {
+	if cfg.adminEmail == "" || !strings.EqualFold(email, cfg.adminEmail) {
+		return database.User{}, false
+	}
+
+	user, err := cfg.db.GrantAdminByEmail(ctx, cfg.adminEmail)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error granting admin to %s: %s", cfg.adminEmail, err)
+		}
+		return database.User{}, false
+	}
+
+	log.Printf("granted admin to %s from ADMIN_EMAIL", cfg.adminEmail)
+	return user, true
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// userConflictMessage names the field behind a unique violation on users.
+func userConflictMessage(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "users_username_key" {
+		return "Username already taken"
+	}
+	return "Email already registered"
+}
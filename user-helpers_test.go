@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"bob@example.com", "bob@example.com", true},
+		{"Bob@Example.COM", "bob@example.com", true},
+		{"  bob@example.com\n", "bob@example.com", true},
+		{"bob+chirpy@mail.example.com", "bob+chirpy@mail.example.com", true},
+		{"", "", false},
+		{"bob", "", false},
+		{"bob@localhost", "", false},
+		{"Bob <bob@example.com>", "", false},
+		{"<bob@example.com>", "", false},
+		{"bob@example.com, eve@example.com", "", false},
+		{strings.Repeat("a", maxEmailLen) + "@example.com", "", false},
+	}
+
+	for _, test := range tests {
+		email, err := normalizeEmail(test.input)
+		if (err == nil) != test.ok {
+			t.Errorf("normalizeEmail(%q) err = %v, expected ok = %v", test.input, err, test.ok)
+			continue
+		}
+		if email != test.expected {
+			t.Errorf("normalizeEmail(%q) = %q, expected %q", test.input, email, test.expected)
+		}
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected sql.NullString
+		ok       bool
+	}{
+		{"", sql.NullString{}, true},
+		{"bob", sql.NullString{String: "bob", Valid: true}, true},
+		{"Bob_The_2nd", sql.NullString{String: "bob_the_2nd", Valid: true}, true},
+		{strings.Repeat("a", 30), sql.NullString{String: strings.Repeat("a", 30), Valid: true}, true},
+		{"bo", sql.NullString{}, false},
+		{strings.Repeat("a", 31), sql.NullString{}, false},
+		{"bob smith", sql.NullString{}, false},
+		{"bob-smith", sql.NullString{}, false},
+		{"@bob", sql.NullString{}, false},
+		{"bøb", sql.NullString{}, false},
+	}
+
+	for _, test := range tests {
+		username, err := normalizeUsername(test.input)
+		if (err == nil) != test.ok {
+			t.Errorf("normalizeUsername(%q) err = %v, expected ok = %v", test.input, err, test.ok)
+			continue
+		}
+		if username != test.expected {
+			t.Errorf("normalizeUsername(%q) = %+v, expected %+v", test.input, username, test.expected)
+		}
+	}
+}